@@ -0,0 +1,159 @@
+package main
+
+import (
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+)
+
+// How an alias was established, from the most to the least authoritative.
+const (
+	LinkTzdataZi = "tzdata.zi" // an "L target link" line of the compact zic input
+	LinkBackward = "backward"  // a "Link target link" line of the backward source file
+	LinkSymlink  = "symlink"
+	LinkHardlink = "hardlink" // both names are the same inode
+	LinkContent  = "content"  // both files have identical contents
+)
+
+// zoneFile is a regular TZif file found while walking a zoneinfo tree.
+type zoneFile struct {
+	root string
+	name string
+	info os.FileInfo
+	hash [sha256.Size]byte
+}
+
+// walkedFiles holds the regular TZif files of the tree being walked, for ResolveLinks.
+var walkedFiles []zoneFile
+
+func recordZoneFile(root, name, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		slog.Error("Could not stat zone file", "path", path, "error", err)
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("Could not read zone file", "path", path, "error", err)
+		return
+	}
+	walkedFiles = append(walkedFiles, zoneFile{root: root, name: name, info: info, hash: sha256.Sum256(data)})
+}
+
+// MakeAlias records alias as a link to zone. An alias that was listed as a zone,
+// or as an alias of another zone, is moved together with its own aliases.
+func (tzi TzInfoMap) MakeAlias(zone string, alias string, method string) {
+	if zone == alias {
+		return
+	}
+	if aliasInfo, exists := tzi[alias]; exists {
+		for _, a := range aliasInfo.Aliases {
+			tzi.MakeAlias(zone, a, aliasInfo.LinkMethods[a])
+		}
+		delete(tzi, alias)
+	}
+	for name, zoneInfo := range tzi {
+		if index, found := slices.BinarySearch(zoneInfo.Aliases, alias); found && name != zone {
+			zoneInfo.Aliases = slices.Delete(zoneInfo.Aliases, index, index+1)
+			delete(zoneInfo.LinkMethods, alias)
+		}
+	}
+	tzi.AddZoneAlias(zone, alias, method)
+}
+
+// aliasOf returns the zone that name is an alias of.
+func (tzi TzInfoMap) aliasOf(name string) (string, bool) {
+	for zone, zoneInfo := range tzi {
+		if _, found := slices.BinarySearch(zoneInfo.Aliases, name); found {
+			return zone, true
+		}
+	}
+	return "", false
+}
+
+// ResolveLinks establishes the aliases of a walked zoneinfo tree that are not symlinks.
+// The link lines of tzdata.zi or backward are authoritative where present; the remaining
+// regular files are grouped by content and then told apart as hardlinks or copies.
+func ResolveLinks(root string) {
+	files := walkedFiles
+	walkedFiles = nil
+
+	declaredZones := make(map[string]bool)
+	if data, method, err := readSource(root); err == nil {
+		if SourceData == nil && len(data.Zones) > 0 {
+			SourceData = data
+		}
+		for _, zone := range data.Zones {
+			declaredZones[zone.Name] = true
+		}
+		for _, link := range data.Links {
+			if _, exists := TzInfos[link.Target]; !exists {
+				slog.Debug("Link target is not in the tree", "target", link.Target, "link", link.Name)
+				continue
+			}
+			_, isZone := TzInfos[link.Name]
+			_, isAlias := TzInfos.aliasOf(link.Name)
+			if isZone || isAlias {
+				TzInfos.MakeAlias(link.Target, link.Name, method)
+			}
+		}
+	} else {
+		Trace("No link source for the tree", "root", root, "error", err)
+	}
+
+	groups := make(map[[sha256.Size]byte][]zoneFile)
+	for _, f := range files {
+		if _, stillZone := TzInfos[f.name]; stillZone {
+			groups[f.hash] = append(groups[f.hash], f)
+		}
+	}
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		canonical := chooseCanonical(group, declaredZones)
+		for _, f := range group {
+			if f.name == canonical.name || declaredZones[f.name] {
+				continue
+			}
+			method := LinkContent
+			if os.SameFile(f.info, canonical.info) {
+				method = LinkHardlink
+			}
+			slog.Debug("Timezone has alias", "timezone", canonical.name, "alias", f.name, "method", method)
+			TzInfos.MakeAlias(canonical.name, f.name, method)
+		}
+	}
+}
+
+// chooseCanonical picks the zone name among files with identical contents: a name declared
+// as a Zone, else the one with the most aliases, else an Area/Location name, else the first.
+func chooseCanonical(group []zoneFile, declaredZones map[string]bool) zoneFile {
+	rank := func(f zoneFile) (bool, int, bool) {
+		return declaredZones[f.name], len(TzInfos[f.name].Aliases), strings.Contains(f.name, "/")
+	}
+	best := group[0]
+	for _, f := range group[1:] {
+		bd, ba, bs := rank(best)
+		fd, fa, fs := rank(f)
+		switch {
+		case fd != bd:
+			if fd {
+				best = f
+			}
+		case fa != ba:
+			if fa > ba {
+				best = f
+			}
+		case fs != bs:
+			if fs {
+				best = f
+			}
+		case f.name < best.name:
+			best = f
+		}
+	}
+	return best
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// Ambiguity is a local time interval [Start, End) that a transition skips or repeats.
+// Start and End are wall clock times of the zone, When is the UTC instant of the transition.
+type Ambiguity struct {
+	Zone     string `json:"Zone"`
+	Kind     string `json:"Kind"` // "skipped" or "repeated"
+	Start    string `json:"Start"`
+	End      string `json:"End"`
+	Duration int    `json:"Duration"`
+	When     string `json:"When"`
+	Before   string `json:"Before"`
+	After    string `json:"After"`
+}
+
+// YearTransitions returns the transitions of the zone that can affect local times in year.
+func YearTransitions(loc *rfc9636.Location, year int) []rfc9636.Transition {
+	// Local times in year are within a day of the UTC year.
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix() - 86400
+	to := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Unix() + 86400
+	return loc.Transitions(from, to)
+}
+
+// ZoneAmbiguities returns the skipped and repeated local time intervals that start in year.
+func ZoneAmbiguities(loc *rfc9636.Location, year int) []Ambiguity {
+	var ambiguities []Ambiguity
+	for _, tx := range YearTransitions(loc, year) {
+		delta := tx.After.Offset - tx.Before.Offset
+		if delta == 0 {
+			continue
+		}
+		a := Ambiguity{Zone: loc.Name(), Kind: "skipped", Duration: delta, When: FormatUnix(tx.When),
+			Before: tx.Before.Name, After: tx.After.Name}
+		start, end := tx.When+int64(tx.Before.Offset), tx.When+int64(tx.After.Offset)
+		if delta < 0 {
+			a.Kind, a.Duration = "repeated", -delta
+			start, end = end, start
+		}
+		if time.Unix(start, 0).UTC().Year() != year {
+			continue
+		}
+		a.Start = time.Unix(start, 0).UTC().Format("2006-01-02T15:04:05")
+		a.End = time.Unix(end, 0).UTC().Format("2006-01-02T15:04:05")
+		ambiguities = append(ambiguities, a)
+	}
+	return ambiguities
+}
+
+// AmbiguitiesCommand lists the local times that are skipped or repeated in the
+// year selected by --year or --at, for the named zones or all zones.
+func AmbiguitiesCommand(args []string) {
+	year := analysisTime.Year()
+	ambiguities := make([]Ambiguity, 0)
+	for _, loc := range LoadZones(args) {
+		ambiguities = append(ambiguities, ZoneAmbiguities(loc, year)...)
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(ambiguities, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"zone", "kind", "start", "end", "duration", "when", "before", "after"})
+		for _, a := range ambiguities {
+			w.Write([]string{a.Zone, a.Kind, a.Start, a.End, strconv.Itoa(a.Duration), a.When, a.Before, a.After})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		zone := ""
+		for _, a := range ambiguities {
+			if a.Zone != zone {
+				zone = a.Zone
+				fmt.Println(zone)
+			}
+			fmt.Printf("  %-8s %s to %s %-9s %s -> %s at %s\n", a.Kind, a.Start, a.End,
+				"("+(time.Duration(a.Duration)*time.Second).String()+")", a.Before, a.After, a.When)
+		}
+	}
+}
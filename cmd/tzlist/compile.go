@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/tzlist/tzsource"
+	"github.com/tzlist/zic"
+)
+
+// compileOptions, set by --bloat and --range, control the TZif files written by compile.
+var compileOptions = zic.DefaultOptions
+
+// outputDir, set by --output, is where commands write the files they create.
+var outputDir = "zoneinfo"
+
+// setBloat handles --bloat, the zic -b option.
+func setBloat(value string) error {
+	switch value {
+	case "slim":
+		compileOptions.Fat = false
+	case "fat":
+		compileOptions.Fat = true
+	default:
+		return fmt.Errorf("must be slim or fat")
+	}
+	return nil
+}
+
+// setRange handles --range, the zic -r option.
+func setRange(value string) error {
+	return zic.ParseRange(&compileOptions, value)
+}
+
+// CompileCommand compiles the tzdata source files given as arguments, or the source of the
+// zone directories, into a tree of TZif files below outputDir like zic does.
+func CompileCommand(args []string) {
+	var data *tzsource.Data
+	if len(args) > 0 {
+		data = &tzsource.Data{}
+		for _, path := range args {
+			if err := data.ReadFile(path); err != nil {
+				Fatal("Error reading source", "error", err)
+			}
+		}
+	} else {
+		data = loadSource()
+	}
+
+	files, err := zic.Compile(data, compileOptions)
+	if err != nil {
+		Fatal("Error compiling source", "error", err)
+	}
+
+	for _, zone := range data.Zones {
+		if err := writeZoneFile(zone.Name, files[zone.Name]); err != nil {
+			Fatal("Error writing zone", "zone", zone.Name, "error", err)
+		}
+	}
+	// Links become hard links to their zone where the file system allows it.
+	for _, link := range data.Links {
+		path := filepath.Join(outputDir, link.Name)
+		os.Remove(path)
+		if zone, ok := SourceZone(data, link.Target); ok {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+				if err := os.Link(filepath.Join(outputDir, zone.Name), path); err == nil {
+					continue
+				}
+			}
+		}
+		if err := writeZoneFile(link.Name, files[link.Name]); err != nil {
+			Fatal("Error writing link", "link", link.Name, "error", err)
+		}
+	}
+	slog.Info("Compiled", "zones", len(data.Zones), "links", len(data.Links), "directory", outputDir,
+		"tzdata", data.Version)
+}
+
+// writeZoneFile writes the TZif data of a zone below outputDir.
+func writeZoneFile(name string, tzif []byte) error {
+	path := filepath.Join(outputDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, tzif, 0o644)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// ZoneTime is an instant as seen in one zone.
+type ZoneTime struct {
+	Zone   string `json:"Zone"`
+	Time   string `json:"Time"`
+	Abbr   string `json:"Abbr"`
+	Offset int    `json:"Offset"`
+	IsDst  bool   `json:"IsDst"`
+}
+
+// Conversion is the result of converting one instant from the source zone to the targets.
+// Status is "normal", "gap" when the source wall clock time was skipped, or
+// "overlap-earlier" and "overlap-later" when it occurred twice. With --policy a
+// single instant is chosen and Status is "normal", "gap" or "overlap".
+type Conversion struct {
+	Input   string     `json:"Input"`
+	Status  string     `json:"Status"`
+	UTC     string     `json:"UTC"`
+	Source  ZoneTime   `json:"Source"`
+	Targets []ZoneTime `json:"Targets"`
+}
+
+func NewZoneTime(loc *rfc9636.Location, sec int64) ZoneTime {
+	tt, _, _ := loc.Lookup(sec)
+	return ZoneTime{
+		Zone:   loc.Name(),
+		Time:   time.Unix(sec, 0).In(time.FixedZone(tt.Name, tt.Offset)).Format(time.RFC3339),
+		Abbr:   tt.Name,
+		Offset: tt.Offset,
+		IsDst:  tt.IsDST,
+	}
+}
+
+// ConvertTime converts the timestamp, a wall clock time in the source zone or an
+// RFC 3339 or @unixseconds instant, to the target zones.
+func ConvertTime(input string, source *rfc9636.Location, targets []*rfc9636.Location) ([]Conversion, error) {
+	var instants []int64
+	status := []string{"normal"}
+	if t, err := time.Parse(time.RFC3339, input); err == nil {
+		instants = []int64{t.Unix()}
+	} else if strings.HasPrefix(input, "@") {
+		t, err := ParseTimestamp(input)
+		if err != nil {
+			return nil, err
+		}
+		instants = []int64{t.Unix()}
+	} else {
+		wall, err := ParseTimestamp(input)
+		if err != nil {
+			return nil, err
+		}
+		wc := source.WallClock(wall.Unix())
+		switch {
+		case wallClockPolicy != nil:
+			sec, _, err := source.Resolve(wc.Local, *wallClockPolicy)
+			if err != nil {
+				return nil, fmt.Errorf("%s in %s: %w", input, source.Name(), err)
+			}
+			instants = []int64{sec}
+			if wc.Kind != rfc9636.WallClockUnique {
+				status = []string{wc.Kind.String()}
+			}
+		case wc.Kind == rfc9636.WallClockGap:
+			// Like mktime, read a skipped time with the offset in force before the gap,
+			// which moves it forward by the size of the gap.
+			sec, _, _ := source.Resolve(wc.Local, rfc9636.PolicyLater)
+			instants, status = []int64{sec}, []string{"gap"}
+		case wc.Kind == rfc9636.WallClockOverlap:
+			instants, status = wc.Instants, []string{"overlap-earlier", "overlap-later"}
+		default:
+			instants = wc.Instants
+		}
+	}
+
+	conversions := make([]Conversion, 0, len(instants))
+	for i, sec := range instants {
+		c := Conversion{Input: input, Status: status[i], UTC: FormatUnix(sec), Source: NewZoneTime(source, sec)}
+		for _, target := range targets {
+			c.Targets = append(c.Targets, NewZoneTime(target, sec))
+		}
+		conversions = append(conversions, c)
+	}
+	return conversions, nil
+}
+
+// ConvertCommand converts a time in a source zone to one or more target zones:
+//
+//	tzlist convert <timestamp> <source zone> <target zone>...
+func ConvertCommand(args []string) {
+	if len(args) < 3 {
+		Fatal("Usage: tzlist convert <timestamp> <source zone> <target zone>...")
+	}
+	locs := LoadZones(args[1:])
+	conversions, err := ConvertTime(args[0], locs[0], locs[1:])
+	if err != nil {
+		Fatal("Could not convert time", "error", err)
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(conversions, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"input", "status", "utc", "zone", "time", "abbr", "offset", "is_dst"})
+		for _, c := range conversions {
+			for _, zt := range append([]ZoneTime{c.Source}, c.Targets...) {
+				w.Write([]string{c.Input, c.Status, c.UTC, zt.Zone, zt.Time, zt.Abbr, strconv.Itoa(zt.Offset), strconv.FormatBool(zt.IsDst)})
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		for _, c := range conversions {
+			switch c.Status {
+			case "gap":
+				fmt.Printf("%s does not exist in %s, the clocks skip over it; using %s\n", c.Input, c.Source.Zone, c.Source.Time)
+			case "overlap-earlier":
+				fmt.Printf("%s occurs twice in %s; the earlier one:\n", c.Input, c.Source.Zone)
+			case "overlap-later":
+				fmt.Printf("%s occurs twice in %s; the later one:\n", c.Input, c.Source.Zone)
+			case "overlap":
+				fmt.Printf("%s occurs twice in %s; using %s\n", c.Input, c.Source.Zone, c.Source.Time)
+			}
+			for _, zt := range append([]ZoneTime{c.Source}, c.Targets...) {
+				fmt.Printf("  %-30s %s %-6s (UTC%s)\n", zt.Zone, zt.Time, zt.Abbr, FormatUTCOffset(zt.Offset))
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/tzlist/zonetab"
+)
+
+// CountryNames maps ISO 3166 codes to the names in iso3166.tab.
+var CountryNames = make(map[string]string)
+
+// zoneTabNames are the names that zone1970.tab or zone.tab list, zones and aliases alike.
+var zoneTabNames = make(map[string]bool)
+
+// countryFilter, set by --country, keeps only the zones used in these countries.
+var countryFilter []string
+
+// LoadZoneTabs attaches the countries, coordinates and comments of zone1970.tab and
+// zone.tab in root to the zones. zone1970.tab is preferred; zone.tab only adds countries,
+// including those it lists under an alias of the zone.
+func LoadZoneTabs(root string) {
+	if names, err := zonetab.ReadISO3166(root + "iso3166.tab"); err == nil {
+		for code, name := range names {
+			CountryNames[code] = name
+		}
+	} else {
+		Trace("Country table is not available", "path", root+"iso3166.tab", "error", err)
+	}
+
+	for _, file := range []string{"zone1970.tab", "zone.tab"} {
+		rows, err := zonetab.ReadZoneTab(root + file)
+		if err != nil {
+			Trace("Zone table is not available", "path", root+file, "error", err)
+			continue
+		}
+		for _, row := range rows {
+			zoneTabNames[row.TZ] = true
+			name := row.TZ
+			if _, exists := TzInfos[name]; !exists {
+				if name, exists = TzInfos.aliasOf(row.TZ); !exists {
+					slog.Debug("Zone table entry is not in the tree", "file", file, "zone", row.TZ)
+					continue
+				}
+			}
+			zoneInfo := TzInfos[name]
+			if zoneInfo.Geo.TZ == "" && name == row.TZ {
+				zoneInfo.Geo = row
+				zoneInfo.Geo.Countries = slices.Clone(row.Countries)
+			} else {
+				for _, code := range row.Countries {
+					if !slices.Contains(zoneInfo.Geo.Countries, code) {
+						zoneInfo.Geo.Countries = append(zoneInfo.Geo.Countries, code)
+					}
+				}
+			}
+			TzInfos[name] = zoneInfo
+		}
+	}
+}
+
+// FilterCountries removes the zones not used in any of the --country countries.
+func (tzi TzInfoMap) FilterCountries() {
+	if len(countryFilter) == 0 {
+		return
+	}
+	for name, zoneInfo := range tzi {
+		if !slices.ContainsFunc(zoneInfo.Geo.Countries, func(code string) bool {
+			return slices.Contains(countryFilter, code)
+		}) {
+			delete(tzi, name)
+		}
+	}
+}
+
+// CountryList formats country codes with their names, "DE Germany, CH Switzerland".
+func CountryList(codes []string) string {
+	described := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if name, exists := CountryNames[code]; exists {
+			code += " " + name
+		}
+		described = append(described, code)
+	}
+	return strings.Join(described, ", ")
+}
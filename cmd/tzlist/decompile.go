@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/tzlist/zic"
+)
+
+// DecompileCommand prints zic source reconstructed from TZif files or zones, and
+// warns when compiling it back would not give the same local times.
+func DecompileCommand(args []string) {
+	if len(args) == 0 {
+		Fatal("Usage: tzlist decompile <file or zone>...")
+	}
+
+	var sources []ZoneSource
+	var texts []string
+	for _, arg := range args {
+		loc := LoadZoneFile(arg)
+		data, err := zic.Decompile(loc)
+		if err != nil {
+			Fatal("Could not decompile zone", "zone", arg, "error", err)
+		}
+		if err := zic.Verify(loc, data); err != nil {
+			slog.Warn("Decompiled source does not reproduce the zone", "zone", arg, "error", err)
+		}
+
+		zone := data.Zones[0]
+		zs := ZoneSource{Zone: zone.Name, Name: arg, Rules: make(map[string][]string)}
+		for _, zl := range zone.Lines {
+			zs.Lines = append(zs.Lines, zl.String())
+		}
+		text := fmt.Sprintf("# Decompiled from %s", arg)
+		if loc.Extend() != "" {
+			text += fmt.Sprintf(", footer %s", loc.Extend())
+		}
+		text += "\n"
+		for _, ruleName := range zone.RuleNames() {
+			for _, rule := range data.Rules[ruleName] {
+				zs.Rules[ruleName] = append(zs.Rules[ruleName], rule.String())
+				text += rule.String() + "\n"
+			}
+		}
+		sources = append(sources, zs)
+		texts = append(texts, text+zone.String()+"\n")
+	}
+
+	if writeZoneSources(sources) {
+		return
+	}
+	fmt.Print(strings.Join(texts, "\n"))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// legacyZoneReplacements maps the POSIX-style zones that tzdata 2024b turned into
+// links to the zones they now link to. Older and backzone builds still ship them as zones.
+var legacyZoneReplacements = map[string]string{
+	"CET":     "Europe/Brussels",
+	"CST6CDT": "America/Chicago",
+	"EET":     "Europe/Athens",
+	"EST":     "America/Panama",
+	"EST5EDT": "America/New_York",
+	"HST":     "Pacific/Honolulu",
+	"MET":     "Europe/Brussels",
+	"MST":     "America/Phoenix",
+	"MST7MDT": "America/Denver",
+	"PST8PDT": "America/Los_Angeles",
+	"WET":     "Europe/Lisbon",
+}
+
+// etcGMTOffset matches the sign-inverted fixed offset zones such as Etc/GMT+5.
+var etcGMTOffset = regexp.MustCompile(`^Etc/GMT[+-][0-9]+$`)
+
+// hideDeprecated, set by --hide-deprecated, drops deprecated zones and aliases from the lists.
+var hideDeprecated bool
+
+// AliasDeprecation is whether an alias is kept only for backward compatibility and which
+// name replaces it. Unknown is set instead when no zone table tells current names apart.
+type AliasDeprecation struct {
+	Deprecated bool   `json:"Deprecated"`
+	Unknown    bool   `json:"Unknown,omitempty"`
+	ReplacedBy string `json:"ReplacedBy,omitempty"`
+}
+
+// Deprecation reports whether a zone name is kept only for backward compatibility and
+// which current zone, if any, replaces it. It covers the names that the tree ships as
+// zones; aliases are classified by AliasDeprecation.
+func Deprecation(zone string) (deprecated bool, replacedBy string) {
+	if replacement, exists := legacyZoneReplacements[zone]; exists {
+		return true, replacement
+	}
+	if etcGMTOffset.MatchString(zone) {
+		return true, ""
+	}
+	// Other names outside the Area/Location scheme, such as Factory, are legacy as well.
+	// The UTC and GMT names are links, so the zones remaining here have no good replacement.
+	return !strings.Contains(zone, "/"), ""
+}
+
+// AliasDeprecation classifies an alias of zone. The links that zone1970.tab or zone.tab
+// list by name, such as Europe/Vatican, are current names of a country's zone; every other
+// link, from the backward file or its copy in tzdata.zi, is kept for backward compatibility
+// and replaced by the zone, or by the zone's own replacement when it is deprecated too.
+// Without a zone table, as in minimal containers and zic output directories, tzdata.zi
+// mixes both kinds of links, so the alias is left unknown rather than deprecated.
+func (zoneInfo TzInfoType) AliasDeprecation(zone, alias string) AliasDeprecation {
+	if len(zoneTabNames) == 0 {
+		return AliasDeprecation{Unknown: true}
+	}
+	if zoneTabNames[alias] {
+		return AliasDeprecation{}
+	}
+	if zoneInfo.ReplacedBy != "" {
+		zone = zoneInfo.ReplacedBy
+	}
+	return AliasDeprecation{Deprecated: true, ReplacedBy: zone}
+}
+
+// NameDeprecation classifies a name of the zoneinfo trees, whether a zone or an alias,
+// as MarkDeprecated does for the lists. It scans ZoneDirs first when TzInfos is empty.
+func NameDeprecation(name string) (deprecated bool, replacedBy string) {
+	if len(TzInfos) == 0 {
+		GetOsTimeZones()
+	}
+	if zoneInfo, exists := TzInfos[name]; exists {
+		return zoneInfo.Deprecated, zoneInfo.ReplacedBy
+	}
+	if zone, isAlias := TzInfos.aliasOf(name); isAlias {
+		ad := TzInfos[zone].AliasDeprecation(zone, name)
+		return ad.Deprecated, ad.ReplacedBy
+	}
+	return Deprecation(name)
+}
+
+// MarkDeprecated flags the deprecated zones and aliases and, with --hide-deprecated,
+// removes them from TzInfos.
+func (tzi TzInfoMap) MarkDeprecated() {
+	for name, zoneInfo := range tzi {
+		zoneInfo.Deprecated, zoneInfo.ReplacedBy = Deprecation(name)
+		if hideDeprecated && zoneInfo.Deprecated {
+			delete(tzi, name)
+			continue
+		}
+		zoneInfo.AliasDeprecations = make(map[string]AliasDeprecation, len(zoneInfo.Aliases))
+		for _, alias := range zoneInfo.Aliases {
+			zoneInfo.AliasDeprecations[alias] = zoneInfo.AliasDeprecation(name, alias)
+		}
+		if hideDeprecated {
+			zoneInfo.Aliases = slices.DeleteFunc(zoneInfo.Aliases, func(alias string) bool {
+				if zoneInfo.AliasDeprecations[alias].Deprecated {
+					delete(zoneInfo.AliasDeprecations, alias)
+					delete(zoneInfo.LinkMethods, alias)
+					return true
+				}
+				return false
+			})
+		}
+		tzi[name] = zoneInfo
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// The results of a DoctorCheck.
+const (
+	DoctorOK   = "ok"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// DoctorCheck is one finding of the doctor command.
+type DoctorCheck struct {
+	Check  string   `json:"Check"`
+	Status string   `json:"Status"`
+	Detail string   `json:"Detail"`
+	Items  []string `json:"Items,omitempty"` // the zones or files the finding is about
+}
+
+// DoctorCommand explains the time zone setup of the host: /etc/localtime, TZ, the tzdata
+// version, how the tzdata that Go embeds with time/tzdata, as of the Go release tzlist is
+// built with, differs from the system one in the --year, and the dangling symbolic links
+// of the zone directories. It exits with status 1 when a check fails.
+func DoctorCommand(args []string) {
+	zones, _ := GetOsTimeZones()
+	checks := []DoctorCheck{
+		checkLocaltime(),
+		checkTZ(),
+		checkTzdataVersion(),
+		checkGoTzdata(zones, analysisTime.Year()),
+		checkDanglingLinks(),
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"check", "status", "detail", "items"})
+		for _, c := range checks {
+			w.Write([]string{c.Check, c.Status, c.Detail, strings.Join(c.Items, " ")})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		for _, c := range checks {
+			fmt.Printf("%-6s %-10s %s\n", "["+c.Status+"]", c.Check, c.Detail)
+			for _, item := range c.Items {
+				fmt.Printf("                  %s\n", item)
+			}
+		}
+	}
+
+	failed := 0
+	for _, c := range checks {
+		if c.Status == DoctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		slog.Error("Time zone setup has problems", "failed", failed)
+		os.Exit(1)
+	}
+}
+
+// checkLocaltime checks that /etc/localtime is valid TZif data of a named zone.
+func checkLocaltime() DoctorCheck {
+	c := DoctorCheck{Check: "localtime"}
+	file := rfc9636.DefaultLocaltime
+	data, err := os.ReadFile(file)
+	if err != nil {
+		c.Status, c.Detail = DoctorFail, fmt.Sprintf("%s cannot be read, libc uses UTC: %v", file, err)
+		return c
+	}
+	if layout := rfc9636.Inspect(data); len(layout.Problems) > 0 {
+		c.Status, c.Detail, c.Items = DoctorFail, fmt.Sprintf("%s is not valid TZif data, libc uses UTC", file), layout.Problems
+		return c
+	}
+	lz := rfc9636.ResolveLocal("", false, ZoneDirs)
+	switch {
+	case lz.Problem != "":
+		c.Status, c.Detail = DoctorFail, fmt.Sprintf("%s does not load, libc uses UTC: %s", file, lz.Problem)
+	case lz.Name == "":
+		c.Status, c.Detail = DoctorWarn, fmt.Sprintf("%s is valid TZif data but matches no zone of %s", file, strings.Join(ZoneDirs, ", "))
+	default:
+		c.Status, c.Detail = DoctorOK, fmt.Sprintf("%s is the zone %s (found by %s)", file, lz.Name, lz.NameFrom)
+		if deprecated, replacedBy := NameDeprecation(lz.Name); deprecated && replacedBy != "" {
+			c.Status, c.Detail = DoctorWarn, c.Detail+", which is deprecated in favor of "+replacedBy
+		}
+		if len(lz.Candidates) > 1 {
+			c.Items = lz.Candidates
+		}
+	}
+	return c
+}
+
+// checkTZ checks that TZ, when set, is a zone or a POSIX TZ string libc understands.
+func checkTZ() DoctorCheck {
+	c := DoctorCheck{Check: "TZ"}
+	tz, set := os.LookupEnv("TZ")
+	if !set {
+		c.Status, c.Detail = DoctorOK, "not set, "+rfc9636.DefaultLocaltime+" applies"
+		return c
+	}
+	lz := rfc9636.ResolveLocal(tz, true, ZoneDirs)
+	switch {
+	case lz.Problem != "":
+		c.Status, c.Detail = DoctorFail, fmt.Sprintf("%q does not work, libc uses UTC: %s", tz, lz.Problem)
+	case lz.Form == rfc9636.LocalEmpty:
+		c.Status, c.Detail = DoctorOK, fmt.Sprintf("%q means UTC", tz)
+	case lz.Form == rfc9636.LocalPOSIX:
+		c.Status, c.Detail = DoctorOK, fmt.Sprintf("%q is a POSIX TZ string", tz)
+		if !strings.Contains(tz, ",") && lz.Location.Extend() != tz {
+			c.Status, c.Detail = DoctorWarn, c.Detail+" without DST rules, libc takes them from posixrules"
+		}
+	case lz.Name == "":
+		c.Status, c.Detail = DoctorWarn, fmt.Sprintf("%q is valid TZif data but matches no zone", tz)
+	default:
+		c.Status, c.Detail = DoctorOK, fmt.Sprintf("%q is the zone %s (found by %s)", tz, lz.Name, lz.NameFrom)
+	}
+	return c
+}
+
+// checkTzdataVersion reports the tzdata release of the zone directories.
+func checkTzdataVersion() DoctorCheck {
+	c := DoctorCheck{Check: "tzdata"}
+	if TzdataVersion == "" {
+		c.Status, c.Detail = DoctorWarn, "no tzdata.zi or +VERSION in "+strings.Join(ZoneDirs, ", ")
+		return c
+	}
+	c.Status, c.Detail = DoctorOK, "release "+TzdataVersion
+	return c
+}
+
+// goTzdata is the zoneinfo.zip of the Go release tzlist is built with, the data that
+// time/tzdata embeds in Go programs, and goTzdataRelease the tzdata release it was made
+// from. go generate copies both from the toolchain.
+//
+//go:generate cp $GOROOT/lib/time/zoneinfo.zip tzdata/zoneinfo.zip
+//go:generate sh -c "sed -n 's/^DATA=//p' $GOROOT/lib/time/update.bash > tzdata/VERSION"
+//go:embed tzdata/zoneinfo.zip
+var goTzdata []byte
+
+//go:embed tzdata/VERSION
+var goTzdataRelease string
+
+// checkGoTzdata compares the zones during year with the tzdata embedded in tzlist, the
+// copy that Go programs built with the same Go release use through time/tzdata.
+func checkGoTzdata(zones []string, year int) DoctorCheck {
+	c := DoctorCheck{Check: "go-tzdata"}
+	zr, err := zip.NewReader(bytes.NewReader(goTzdata), int64(len(goTzdata)))
+	if err != nil {
+		c.Status, c.Detail = DoctorFail, "the embedded Go tzdata is not a zip file: "+err.Error()
+		return c
+	}
+	members := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		members[f.Name] = f
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	missing := 0
+	for _, name := range zones {
+		loc := TzInfos[name].Location
+		if loc == nil {
+			continue
+		}
+		f, exists := members[name]
+		if !exists {
+			missing++
+			c.Items = append(c.Items, name+": not in Go's tzdata")
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		goLoc, err := rfc9636.LoadLocationFromTZData("Go's tzdata", data)
+		if err != nil {
+			c.Items = append(c.Items, name+": "+err.Error())
+			continue
+		}
+		if err := loc.Verify(goLoc, from, to); err != nil {
+			c.Items = append(c.Items, name+": "+err.Error())
+		}
+	}
+
+	source := fmt.Sprintf("the tzdata of %s, release %s,", runtime.Version(), strings.TrimSpace(goTzdataRelease))
+	if len(c.Items) == 0 {
+		c.Status, c.Detail = DoctorOK, fmt.Sprintf("%s agrees with the system in %d", source, year)
+		return c
+	}
+	c.Status = DoctorWarn
+	c.Detail = fmt.Sprintf("%s differs from the system in %d for %d zones, %d of them missing",
+		source, year, len(c.Items), missing)
+	return c
+}
+
+// checkDanglingLinks lists the symbolic links of the zone directories whose target does not exist.
+func checkDanglingLinks() DoctorCheck {
+	c := DoctorCheck{Check: "links"}
+	for _, zd := range ZoneDirs {
+		filepath.WalkDir(zd, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.Type()&os.ModeSymlink == 0 {
+				return nil
+			}
+			if _, err := os.Stat(path); err != nil {
+				target, _ := os.Readlink(path)
+				c.Items = append(c.Items, path+" -> "+target)
+			}
+			return nil
+		})
+	}
+	if len(c.Items) > 0 {
+		c.Status, c.Detail = DoctorWarn, fmt.Sprintf("%d dangling symbolic links in the zone directories", len(c.Items))
+	} else {
+		c.Status, c.Detail = DoctorOK, "no dangling symbolic links in the zone directories"
+	}
+	return c
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// dumpLoYear and dumpHiYear, set by --cutoff, limit dump to transitions from the start of
+// dumpLoYear up to the start of dumpHiYear. The defaults are those of zdump.
+var dumpLoYear, dumpHiYear = -500, 2500
+
+// setCutoff handles --cutoff, the zdump -c option: [loyear,]hiyear.
+func setCutoff(value string) error {
+	lo, hi, found := strings.Cut(value, ",")
+	if !found {
+		lo, hi = "", lo
+	}
+	hiYear, err := strconv.Atoi(hi)
+	if err != nil {
+		return fmt.Errorf("invalid year %q", hi)
+	}
+	loYear := dumpLoYear
+	if lo != "" {
+		if loYear, err = strconv.Atoi(lo); err != nil {
+			return fmt.Errorf("invalid year %q", lo)
+		}
+	}
+	if hiYear <= loYear {
+		return fmt.Errorf("%d is not after %d", hiYear, loYear)
+	}
+	dumpLoYear, dumpHiYear = loYear, hiYear
+	return nil
+}
+
+// DumpEntry is one line of the zdump -v output: the local time type in force at Unix.
+type DumpEntry struct {
+	Zone   string `json:"Zone"`
+	UT     string `json:"UT"`
+	Local  string `json:"Local"`
+	Unix   int64  `json:"Unix"`
+	Abbr   string `json:"Abbr"`
+	Offset int    `json:"Offset"`
+	IsDst  bool   `json:"IsDst"`
+}
+
+func NewDumpEntry(zone string, sec int64, tt rfc9636.TimeType) DumpEntry {
+	local := time.Unix(sec, 0).In(time.FixedZone(tt.Name, tt.Offset)).Format(time.RFC3339)
+	return DumpEntry{Zone: zone, UT: FormatUnix(sec), Local: local, Unix: sec, Abbr: tt.Name, Offset: tt.Offset, IsDst: tt.IsDST}
+}
+
+// DumpCommand prints the transitions of the named zones or TZif files, or of all zones,
+// between the --cutoff years like zdump -v -c does, including those the footer describes
+// past the end of the transition table. Unlike zdump it leaves out the lines for the
+// first and last representable times.
+func DumpCommand(args []string) {
+	var locs []*rfc9636.Location
+	if len(args) == 0 {
+		locs = LoadZones(nil)
+	}
+	for _, arg := range args {
+		locs = append(locs, LoadZoneFile(arg))
+	}
+	from := time.Date(dumpLoYear, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(dumpHiYear, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	if outputFormat == "text" {
+		for _, loc := range locs {
+			if err := rfc9636.DumpLocation(os.Stdout, loc, from, to); err != nil {
+				Fatal("Error writing dump", "error", err)
+			}
+		}
+		return
+	}
+
+	var entries []DumpEntry
+	for _, loc := range locs {
+		for _, tx := range loc.Transitions(from, to) {
+			entries = append(entries, NewDumpEntry(loc.Name(), tx.When-1, tx.Before), NewDumpEntry(loc.Name(), tx.When, tx.After))
+		}
+	}
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"zone", "ut", "local", "unix", "abbr", "offset", "is_dst"})
+		for _, e := range entries {
+			w.Write([]string{e.Zone, e.UT, e.Local, strconv.FormatInt(e.Unix, 10), e.Abbr, strconv.Itoa(e.Offset), strconv.FormatBool(e.IsDst)})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	}
+}
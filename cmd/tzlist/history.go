@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// HistoryRange is a span of time during which a zone used one offset and abbreviation.
+// From is empty for the range before the first transition and To is empty for the
+// range after the last one, which the Footer then describes.
+type HistoryRange struct {
+	Zone   string `json:"Zone"`
+	From   string `json:"From,omitempty"`
+	To     string `json:"To,omitempty"`
+	Abbr   string `json:"Abbr"`
+	Offset int    `json:"Offset"`
+	IsDst  bool   `json:"IsDst"`
+	Footer string `json:"Footer,omitempty"`
+}
+
+// ZoneHistory returns every offset and abbreviation recorded in the transition
+// table of the zone together with the range of time it was in force.
+func ZoneHistory(loc *rfc9636.Location) []HistoryRange {
+	first, _, _ := loc.Lookup(-1 << 63)
+	var txs []rfc9636.Transition
+	if end, ok := loc.TableEnd(); ok {
+		txs = loc.Transitions(-1<<63, end+1)
+	}
+
+	ranges := make([]HistoryRange, 0, len(txs)+1)
+	current := HistoryRange{Zone: loc.Name(), Abbr: first.Name, Offset: first.Offset, IsDst: first.IsDST}
+	for _, tx := range txs {
+		current.To = FormatUnix(tx.When)
+		ranges = append(ranges, current)
+		current = HistoryRange{Zone: loc.Name(), From: current.To, Abbr: tx.After.Name, Offset: tx.After.Offset, IsDst: tx.After.IsDST}
+	}
+	current.Footer = loc.Extend()
+	return append(ranges, current)
+}
+
+// FormatUnix formats Unix seconds as an RFC 3339 UTC timestamp.
+func FormatUnix(sec int64) string {
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
+
+// FormatUTCOffset formats seconds east of UTC as +HH:MM, or +HH:MM:SS when needed.
+func FormatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	if offset%60 != 0 {
+		return fmt.Sprintf("%c%02d:%02d:%02d", sign, offset/3600, offset/60%60, offset%60)
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset/60%60)
+}
+
+// HistoryCommand prints the offset and abbreviation history of the named zones,
+// or of all zones, in the format selected by --format.
+func HistoryCommand(args []string) {
+	var ranges []HistoryRange
+	for _, loc := range LoadZones(args) {
+		ranges = append(ranges, ZoneHistory(loc)...)
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(ranges, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"zone", "from", "to", "abbr", "offset", "utc_offset", "is_dst", "footer"})
+		for _, r := range ranges {
+			w.Write([]string{r.Zone, r.From, r.To, r.Abbr, strconv.Itoa(r.Offset), FormatUTCOffset(r.Offset), strconv.FormatBool(r.IsDst), r.Footer})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		zone := ""
+		for _, r := range ranges {
+			if r.Zone != zone {
+				zone = r.Zone
+				fmt.Println(zone)
+			}
+			from, to := r.From, r.To
+			if from == "" {
+				from = "-"
+			}
+			if to == "" {
+				to = "-"
+			}
+			dst := ""
+			if r.IsDst {
+				dst = "DST"
+			}
+			fmt.Printf("  %-20s %-20s %-6s %-9s %-3s %s\n", from, to, r.Abbr, FormatUTCOffset(r.Offset), dst, r.Footer)
+		}
+	}
+}
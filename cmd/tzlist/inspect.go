@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// InspectedFile is the structure of one TZif file as shown by inspect.
+type InspectedFile struct {
+	File string `json:"File"`
+	*rfc9636.Layout
+}
+
+// readZoneData returns the bytes of the file at path or, when there is no such file,
+// of the zone of that name in ZoneDirs.
+func readZoneData(path string) []byte {
+	if data, err := os.ReadFile(path); err == nil {
+		return data
+	}
+	for _, dir := range ZoneDirs {
+		if data, err := rfc9636.LoadTzinfoFromDir(dir, path); err == nil {
+			return data
+		}
+	}
+	Fatal("Could not read zone file", "path", path)
+	return nil
+}
+
+// InspectCommand shows the byte layout of TZif files, including files the parser rejects.
+func InspectCommand(args []string) {
+	if len(args) == 0 {
+		Fatal("Usage: tzlist inspect <file or zone>...")
+	}
+
+	var files []InspectedFile
+	for _, arg := range args {
+		data := readZoneData(arg)
+		files = append(files, InspectedFile{File: arg, Layout: rfc9636.Inspect(data)})
+		if outputFormat == "text" {
+			printLayout(arg, files[len(files)-1].Layout, data)
+		}
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(files, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"file", "section", "offset", "length"})
+		for _, f := range files {
+			for _, s := range layoutSpans(f.Layout) {
+				w.Write([]string{f.File, s.Name, strconv.Itoa(s.Offset), strconv.Itoa(s.Length)})
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	}
+}
+
+// layoutSpans returns the headers, sections, footer and trailing data of l in file order.
+func layoutSpans(l *rfc9636.Layout) []rfc9636.Span {
+	var spans []rfc9636.Span
+	for _, b := range l.Blocks {
+		spans = append(spans, b.Header.Span)
+		spans = append(spans, b.Sections...)
+	}
+	if l.Footer != nil {
+		spans = append(spans, l.Footer.Span)
+	}
+	if l.Trailing != nil {
+		spans = append(spans, *l.Trailing)
+	}
+	return spans
+}
+
+// printLayout prints the layout of the TZif data of file with byte offsets and raw bytes.
+func printLayout(file string, l *rfc9636.Layout, data []byte) {
+	const maxHex = 64
+	fmt.Printf("%s: %d bytes\n", file, l.Size)
+	for _, b := range l.Blocks {
+		h := b.Header
+		// Quoted, so that a corrupted version byte shows up as an escape rather than raw.
+		version := fmt.Sprintf("%q", h.Version)
+		if h.Version == 0 {
+			version += " (1)"
+		}
+		fmt.Printf("0x%04x %s (%d bytes): magic %q version %s\n", h.Offset, h.Name, h.Length, h.Magic, version)
+		fmt.Printf("         isutcnt %d isstdcnt %d leapcnt %d timecnt %d typecnt %d charcnt %d\n",
+			h.Isutcnt, h.Isstdcnt, h.Leapcnt, h.Timecnt, h.Typecnt, h.Charcnt)
+		for i, s := range b.Sections {
+			fmt.Printf("0x%04x %s (%d bytes)\n", s.Offset, s.Name, s.Length)
+			switch i {
+			case 2:
+				for n, tt := range b.Types {
+					fmt.Printf("         0x%04x type %d: %s  utoff %d (%s) isdst %d desigidx %d %q\n",
+						tt.Offset, n, tt.Raw, tt.UTOff, FormatUTCOffset(int(tt.UTOff)), tt.IsDST, tt.DesigIdx, tt.Abbr)
+				}
+			case 3:
+				for _, a := range b.Abbrevs {
+					fmt.Printf("         0x%04x [%d] %q\n", a.Offset, a.Index, a.Text)
+				}
+			case 4:
+				for n, lr := range b.Leaps {
+					fmt.Printf("         0x%04x leap %d: occurrence %d (%s) correction %d\n",
+						lr.Offset, n, lr.Occurrence, FormatUnix(lr.Occurrence), lr.Correction)
+				}
+			}
+		}
+	}
+	if l.Footer != nil {
+		fmt.Printf("0x%04x footer (%d bytes): %q\n", l.Footer.Offset, l.Footer.Length, l.Footer.Text)
+	}
+	if t := l.Trailing; t != nil {
+		raw := data[t.Offset:t.End()]
+		more := ""
+		if len(raw) > maxHex {
+			raw, more = raw[:maxHex], " ..."
+		}
+		fmt.Printf("0x%04x %s (%d bytes): %s%s\n", t.Offset, t.Name, t.Length, hex.EncodeToString(raw), more)
+	}
+	for _, p := range l.Problems {
+		fmt.Printf("Problem: %s\n", p)
+	}
+}
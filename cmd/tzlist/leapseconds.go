@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/tzlist/leapseconds"
+	"github.com/tzlist/rfc9636"
+)
+
+// LeapEntry is one leap second of the table as reported by the leapseconds command.
+type LeapEntry struct {
+	From        string `json:"From"`
+	Unix        int64  `json:"Unix"`
+	TAIMinusUTC int    `json:"TAIMinusUTC"`
+	Correction  int    `json:"Correction"`
+	Occurrence  int64  `json:"Occurrence"` // the time of the leap in the right/ zones
+}
+
+// loadLeapTable returns the leap second table of the first zone directory that has one,
+// from leap-seconds.list or else from leapseconds, and the file it was read from.
+// A second table found in the same directory is checked against the first.
+func loadLeapTable() (*leapseconds.Table, string) {
+	for _, zd := range ZoneDirs {
+		list, listErr := leapseconds.ReadList(filepath.Join(zd, "leap-seconds.list"))
+		if listErr != nil && !os.IsNotExist(listErr) {
+			slog.Error("Invalid leap second list", "file", filepath.Join(zd, "leap-seconds.list"), "error", listErr)
+		}
+		zic, zicErr := leapseconds.ReadLeapseconds(filepath.Join(zd, "leapseconds"))
+		if zicErr != nil && !os.IsNotExist(zicErr) {
+			slog.Error("Invalid leapseconds file", "file", filepath.Join(zd, "leapseconds"), "error", zicErr)
+		}
+		switch {
+		case listErr == nil && zicErr == nil:
+			if !slices.Equal(list.Leaps, zic.Leaps) {
+				slog.Warn("leap-seconds.list and leapseconds disagree", "directory", zd,
+					"list", len(list.Leaps), "leapseconds", len(zic.Leaps))
+			}
+			return list, filepath.Join(zd, "leap-seconds.list")
+		case listErr == nil:
+			return list, filepath.Join(zd, "leap-seconds.list")
+		case zicErr == nil:
+			return zic, filepath.Join(zd, "leapseconds")
+		}
+	}
+	Fatal("No leap-seconds.list or leapseconds file in the zone directories")
+	return nil, ""
+}
+
+// checkRightZones compares the leap records of the TZif files below the right/ directories
+// with the leap second table and returns the number of files checked and mismatched.
+func checkRightZones(table *leapseconds.Table) (checked, mismatched int) {
+	for _, zd := range ZoneDirs {
+		root := filepath.Join(zd, "right")
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			layout := rfc9636.Inspect(data)
+			if len(layout.Problems) > 0 || len(layout.Blocks) == 0 {
+				Trace("File is not a timezone file", "file", path)
+				return nil
+			}
+			checked++
+			var records []leapseconds.Record
+			for _, lr := range layout.Blocks[len(layout.Blocks)-1].Leaps {
+				records = append(records, leapseconds.Record{Occurrence: lr.Occurrence, Correction: int(lr.Correction)})
+			}
+			if err := table.CheckRecords(records); err != nil {
+				slog.Warn("Leap records differ from the table", "file", path, "error", err)
+				mismatched++
+			}
+			return nil
+		})
+	}
+	return checked, mismatched
+}
+
+// LeapsecondsCommand prints the leap second table of the tz database after verifying the
+// hash of leap-seconds.list, warns when the table has expired, and cross-checks it
+// against the leap records of the right/ zones.
+func LeapsecondsCommand(args []string) {
+	table, file := loadLeapTable()
+	slog.Info("Leap second table", "file", file, "leaps", len(table.Leaps), "updated", FormatUnix(table.Updated),
+		"expires", FormatUnix(table.Expires), "hash", table.Hash)
+	if table.Expired(time.Now()) {
+		slog.Warn("The leap second table has expired, update tzdata", "file", file, "expires", FormatUnix(table.Expires))
+	}
+	if checked, mismatched := checkRightZones(table); checked > 0 {
+		slog.Info("Checked right/ zones", "files", checked, "mismatched", mismatched)
+	}
+
+	occurrences := table.Occurrences()
+	entries := make([]LeapEntry, 0, len(table.Leaps))
+	for i, leap := range table.Leaps {
+		entries = append(entries, LeapEntry{From: FormatUnix(leap.Time), Unix: leap.Time,
+			TAIMinusUTC: leapseconds.InitialTAIOffset + leap.Correction, Correction: leap.Correction, Occurrence: occurrences[i]})
+	}
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"from", "unix", "tai_minus_utc", "correction", "occurrence"})
+		for _, e := range entries {
+			w.Write([]string{e.From, strconv.FormatInt(e.Unix, 10), strconv.Itoa(e.TAIMinusUTC), strconv.Itoa(e.Correction), strconv.FormatInt(e.Occurrence, 10)})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		for _, e := range entries {
+			fmt.Printf("%-20s TAI-UTC %3d  correction %+3d  right/ %d\n", e.From, e.TAIMinusUTC, e.Correction, e.Occurrence)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// LocalEntry is the local zone as reported by the local command.
+type LocalEntry struct {
+	TZ         *string  `json:"TZ"` // null when TZ is not set
+	Form       string   `json:"Form"`
+	File       string   `json:"File,omitempty"`
+	Zone       string   `json:"Zone,omitempty"`
+	ZoneFrom   string   `json:"ZoneFrom,omitempty"`
+	Candidates []string `json:"Candidates,omitempty"`
+	Deprecated bool     `json:"Deprecated,omitempty"`
+	ReplacedBy string   `json:"ReplacedBy,omitempty"`
+	At         string   `json:"At"`
+	Abbr       string   `json:"Abbr"`
+	Offset     int      `json:"Offset"`
+	IsDst      bool     `json:"IsDst"`
+	PosixTZ    string   `json:"PosixTZ"`
+	Problem    string   `json:"Problem,omitempty"`
+}
+
+// LocalCommand shows the local zone libc uses at --at, from TZ or /etc/localtime, and
+// how its name was found. An argument is resolved as a TZ value instead of the variable.
+func LocalCommand(args []string) {
+	if len(args) > 1 {
+		Fatal("Usage: tzlist local [<TZ value>]")
+	}
+	tz, set := os.LookupEnv("TZ")
+	if len(args) == 1 {
+		tz, set = args[0], true
+	}
+	sources := ZoneDirs
+	if dir := os.Getenv("TZDIR"); dir != "" && !zoneDirsFromFlag {
+		sources = []string{dir}
+	}
+
+	lz := rfc9636.ResolveLocal(tz, set, sources)
+	if lz.Problem != "" {
+		slog.Warn("libc falls back to UTC", "tz", tz, "problem", lz.Problem)
+	}
+	tt, _, _ := lz.Location.Lookup(analysisTime.Unix())
+	entry := LocalEntry{Form: lz.Form, File: lz.File, Zone: lz.Name, ZoneFrom: lz.NameFrom, Candidates: lz.Candidates,
+		At: FormatUnix(analysisTime.Unix()), Abbr: tt.Name, Offset: tt.Offset, IsDst: tt.IsDST,
+		PosixTZ: lz.Location.Extend(), Problem: lz.Problem}
+	if set {
+		entry.TZ = &tz
+	}
+	if lz.Name != "" {
+		entry.Deprecated, entry.ReplacedBy = NameDeprecation(lz.Name)
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(entry, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"tz", "form", "file", "zone", "zone_from", "at", "abbr", "offset", "is_dst", "posix_tz", "problem"})
+		w.Write([]string{tz, entry.Form, entry.File, entry.Zone, entry.ZoneFrom, entry.At, entry.Abbr,
+			strconv.Itoa(entry.Offset), strconv.FormatBool(entry.IsDst), entry.PosixTZ, entry.Problem})
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		if set {
+			fmt.Printf("TZ:     %q (%s)\n", tz, entry.Form)
+		} else {
+			fmt.Printf("TZ:     not set, using %s\n", rfc9636.DefaultLocaltime)
+		}
+		if entry.File != "" {
+			fmt.Printf("File:   %s\n", entry.File)
+		}
+		switch {
+		case entry.Zone != "":
+			fmt.Printf("Zone:   %s (from %s)\n", entry.Zone, entry.ZoneFrom)
+		case entry.Form == rfc9636.LocalPOSIX:
+			fmt.Println("Zone:   none, TZ is a POSIX TZ string")
+		default:
+			fmt.Println("Zone:   none found")
+		}
+		if len(entry.Candidates) > 1 {
+			fmt.Printf("        same contents as %s\n", strings.Join(entry.Candidates[1:], ", "))
+		}
+		if entry.Deprecated {
+			if entry.ReplacedBy != "" {
+				fmt.Printf("        deprecated, use %s\n", entry.ReplacedBy)
+			} else {
+				fmt.Println("        deprecated, kept for backward compatibility")
+			}
+		}
+		dst := "standard time"
+		if entry.IsDst {
+			dst = "daylight saving time"
+		}
+		fmt.Printf("At:     %s %s (UTC %s, %s)\n", entry.At, entry.Abbr, FormatUTCOffset(entry.Offset), dst)
+		fmt.Printf("Footer: %s\n", entry.PosixTZ)
+		if entry.Problem != "" {
+			fmt.Printf("Problem: %s, libc uses UTC\n", entry.Problem)
+		}
+	}
+}
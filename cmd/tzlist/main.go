@@ -2,29 +2,111 @@ package main
 
 import (
 	"context"
+	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/spf13/pflag"
 	"github.com/tzlist/posix/tzposix"
 	"github.com/tzlist/rfc9636"
+	"github.com/tzlist/zonetab"
 	"io/ioutil"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// SchedulerSchemaVersion is the version of the JSON document written by GenerateJson.
+// Version 1 was the bare list or map of SchedulerJson entries with only the prose fields.
+const SchedulerSchemaVersion = 2
+
+//go:embed schema/scheduler-v2.schema.json
+var SchedulerSchema []byte
+
+// SchedulerDocument is the top level of the generated JSON file.
+// Zones holds either a []SchedulerJson or a SchedulerZoneMap depending on jsonFileFormat.
+type SchedulerDocument struct {
+	SchemaVersion int    `json:"SchemaVersion"`
+	TzdataVersion string `json:"TzdataVersion,omitempty"`
+	Zones         any    `json:"Zones"`
+}
+
 type SchedulerJson struct {
-	Name    string   `json:"Name,omitempty"`
-	HasDst  bool     `json:"HasDst"`
-	Std     string   `json:"Std"`
-	Dst     string   `json:"Dst,omitempty"`
-	Aliases []string `json:"Aliases,omitempty"`
-	Rules   string   `json:"Rules,omitempty"`
+	Name              string                      `json:"Name,omitempty"`
+	HasDst            bool                        `json:"HasDst"`
+	Std               string                      `json:"Std"`
+	Dst               string                      `json:"Dst,omitempty"`
+	Aliases           []string                    `json:"Aliases,omitempty"`
+	AliasMethods      map[string]string           `json:"AliasMethods,omitempty"`
+	AliasDeprecations map[string]AliasDeprecation `json:"AliasDeprecations,omitempty"`
+	Deprecated        bool                        `json:"Deprecated,omitempty"`
+	ReplacedBy        string                      `json:"ReplacedBy,omitempty"`
+	Countries         []string                    `json:"Countries,omitempty"`
+	CountryNames      []string                    `json:"CountryNames,omitempty"`
+	Coordinates       *SchedulerCoordinates       `json:"Coordinates,omitempty"`
+	Comment           string                      `json:"Comment,omitempty"`
+	SourceRules       []string                    `json:"SourceRules,omitempty"`
+	Variants          map[string]ZoneVariant      `json:"Variants,omitempty"`
+	Rules             string                      `json:"Rules,omitempty"`
+	PosixTZ           string                      `json:"PosixTZ"`
+	StdAbbr           string                      `json:"StdAbbr"`
+	StdOffset         int                         `json:"StdOffset"`
+	PermanentDst      bool                        `json:"PermanentDst,omitempty"`
+	DstAbbr           string                      `json:"DstAbbr,omitempty"`
+	DstOffset         *int                        `json:"DstOffset,omitempty"`
+	DstSaving         *int                        `json:"DstSaving,omitempty"`
+	DstStart          *SchedulerRule              `json:"DstStart,omitempty"`
+	DstEnd            *SchedulerRule              `json:"DstEnd,omitempty"`
+
+	Transitions []SchedulerTransition `json:"Transitions,omitempty"`
+}
+
+// SchedulerTransition is a single clock change. Offsets are seconds east of UTC,
+// Abbr and IsDst describe the time in effect after the change.
+type SchedulerTransition struct {
+	When         string `json:"When"`
+	Unix         int64  `json:"Unix"`
+	OffsetBefore int    `json:"OffsetBefore"`
+	OffsetAfter  int    `json:"OffsetAfter"`
+	Abbr         string `json:"Abbr"`
+	IsDst        bool   `json:"IsDst"`
+}
+
+// SchedulerCoordinates is the principal location of a zone from zone1970.tab or zone.tab.
+type SchedulerCoordinates struct {
+	ISO6709   string  `json:"ISO6709"`
+	Latitude  float64 `json:"Latitude"`
+	Longitude float64 `json:"Longitude"`
+}
+
+// SetGeo fills in the country and location fields from the zone tables.
+func (zj *SchedulerJson) SetGeo(geo zonetab.Zone) {
+	zj.Countries = geo.Countries
+	for _, code := range geo.Countries {
+		zj.CountryNames = append(zj.CountryNames, CountryNames[code])
+	}
+	if geo.Coordinates != "" {
+		zj.Coordinates = &SchedulerCoordinates{ISO6709: geo.Coordinates, Latitude: geo.Latitude, Longitude: geo.Longitude}
+	}
+	zj.Comment = geo.Comment
+}
+
+// SchedulerRule is the structured form of a POSIX TZ start or end rule.
+// Month, Week and Weekday apply to the MonthWeekDay kind, Day to the Julian and DayOfYear kinds;
+// the fields that do not apply are zero.
+// Time is the local wall clock time of the change in seconds after midnight.
+type SchedulerRule struct {
+	Kind    string `json:"Kind"`
+	Month   int    `json:"Month"`
+	Week    int    `json:"Week"`
+	Weekday int    `json:"Weekday"`
+	Day     int    `json:"Day"`
+	Time    int    `json:"Time"`
 }
 
 const (
@@ -48,14 +130,25 @@ type TzZoneType struct {
 	Offset int
 }
 
-// Offsets [0] standard time
+// Offsets [0] standard time, or the daylight time of a permanent DST zone
 // Offsets [1] daylight savings time
 // len[Offsets] > 1 Has daylight savings time
 
 type TzInfoType struct {
-	Aliases []string
-	Offsets []TzZoneType
-	Extend  string
+	Aliases           []string
+	LinkMethods       map[string]string           // alias -> how the link was established, see LinkSymlink
+	AliasDeprecations map[string]AliasDeprecation // alias -> whether it is deprecated, see MarkDeprecated
+	Offsets           []TzZoneType
+	Extend            string
+	Location          *rfc9636.Location
+	Year              ZoneYear
+
+	Deprecated bool   // kept only for backward compatibility
+	ReplacedBy string // the current zone to use instead, if any
+
+	Geo zonetab.Zone // countries, coordinates and comment from zone1970.tab and zone.tab
+
+	Variants map[string]ZoneVariant // copies of the zone in the VariantDirs, with --include-right
 }
 
 var SchedulerZoneSlices []SchedulerJson = make([]SchedulerJson, 0, 800)
@@ -70,9 +163,52 @@ type TzInfoMap map[string]TzInfoType
 
 var jsonFileFormat string = "slices"
 
+// Transitions included in the scheduler JSON: either the next numTransitions
+// starting at transitionsFrom, or every transition in [transitionsFrom, transitionsTo).
+var numTransitions int
+var transitionsFrom, transitionsTo time.Time
+
+var zoneDirsFromFlag bool
+
+// wallClockPolicy, set by --policy, resolves skipped and repeated wall clock times to a single instant.
+var wallClockPolicy *rfc9636.Policy
+
+// outputFormat selects text, csv or json output for the commands that support it.
+var outputFormat = "text"
+
+// Commands are selected by the first positional argument and receive the remaining ones.
+// Without a command tzlist lists the zones.
+var Commands = map[string]func(args []string){
+	"ambiguities": AmbiguitiesCommand,
+	"compile":     CompileCommand,
+	"decompile":   DecompileCommand,
+	"doctor":      DoctorCommand,
+	"dump":        DumpCommand,
+	"convert":     ConvertCommand,
+	"history":     HistoryCommand,
+	"rewrite":     RewriteCommand,
+	"inspect":     InspectCommand,
+	"leapseconds": LeapsecondsCommand,
+	"local":       LocalCommand,
+	"mkzone":      MkzoneCommand,
+	"rules":       RulesCommand,
+	"special":     SpecialCommand,
+}
+
+// analysisTime is the instant the zones are analyzed at, set by --at or --year.
+var analysisTime = time.Now()
+
+// ZoneDirs are the zoneinfo trees scanned by GetOsTimeZones, replaced by --zoneinfo.
+var ZoneDirs = []string{
+	// Update path according to your OS
+	"/usr/share/zoneinfo/",
+	"/usr/share/lib/zoneinfo/",
+	"/usr/lib/locale/TZ/",
+}
+
 var TzInfos = make(TzInfoMap)
 
-func (tzi TzInfoMap) AddZoneAlias(zone string, alias string) {
+func (tzi TzInfoMap) AddZoneAlias(zone string, alias string, method string) {
 	zoneInfo, exists := tzi[zone]
 	if !exists {
 		zoneInfo = NewTzInfo()
@@ -82,6 +218,7 @@ func (tzi TzInfoMap) AddZoneAlias(zone string, alias string) {
 	if !found {
 		zoneInfo.Aliases = slices.Insert(zoneInfo.Aliases, index, alias)
 	}
+	zoneInfo.LinkMethods[alias] = method
 	tzi[zone] = zoneInfo
 	return
 
@@ -93,36 +230,169 @@ func (tzi TzInfoMap) Add(zone string, data *rfc9636.Location) {
 		zoneInfo = NewTzInfo()
 	}
 
-	year := time.Now().Year()
-	loc, err := time.LoadLocation(zone)
-	if err != nil {
-		Fatal("LoadLocation failed", "error", err)
-
+	zy := ClassifyYear(data, analysisTime.Year())
+	if zy.PermanentDst {
+		zoneInfo.Offsets = append(zoneInfo.Offsets, TzZoneType{zy.Dst.Name, zy.Dst.Offset})
+	} else {
+		zoneInfo.Offsets = append(zoneInfo.Offsets, TzZoneType{zy.Std.Name, zy.Std.Offset})
+		if zy.HasDst {
+			zoneInfo.Offsets = append(zoneInfo.Offsets, TzZoneType{zy.Dst.Name, zy.Dst.Offset})
+		}
 	}
+	zoneInfo.Year = zy
 
-	// Check offset on a winter date (Jan 1) and a summer date (Jul 1)
-	winterTime := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
-	summerTime := time.Date(year, time.July, 1, 0, 0, 0, 0, loc)
+	zoneInfo.Extend = data.Extend()
+	zoneInfo.Location = data
+	tzi[zone] = zoneInfo
+}
 
-	xst, winterOffset := winterTime.Zone()
-	xdt, summerOffset := summerTime.Zone()
+// ZoneYear is the standard and daylight saving time classification of a zone for one year.
+// It is based on the isDST flags of the TZif local time types and of the footer,
+// so it holds for both hemispheres, negative DST and permanent DST.
+type ZoneYear struct {
+	Year         int
+	Std          rfc9636.TimeType
+	Dst          rfc9636.TimeType
+	HasDst       bool  // the zone switches between standard and daylight time during Year
+	PermanentDst bool  // the zone is on daylight time for all of Year
+	DstStart     int64 // Unix time of the first change into daylight time in Year, 0 if none
+	DstEnd       int64 // Unix time of the first change out of daylight time in Year, 0 if none
+}
 
-	zoneInfo.Offsets = append(zoneInfo.Offsets, TzZoneType{xst, winterOffset})
+// Southern reports whether the daylight saving period spans the new year,
+// as it does in the southern hemisphere.
+func (zy ZoneYear) Southern() bool {
+	return zy.HasDst && zy.DstEnd < zy.DstStart
+}
 
-	if winterOffset != summerOffset {
-		zoneInfo.Offsets = append(zoneInfo.Offsets, TzZoneType{xdt, summerOffset})
+// ClassifyYear returns the standard and daylight time types in force in loc during
+// the UTC calendar year. DstStart and DstEnd hold the first change into and out of
+// daylight time within that year; both are 0 unless the zone switches between
+// standard and daylight time in the year, so they are 0 for permanent DST as well.
+func ClassifyYear(loc *rfc9636.Location, year int) ZoneYear {
+	zy := ZoneYear{Year: year}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	first, _, _ := loc.Lookup(start)
+	used := []rfc9636.TimeType{first}
+	for _, tx := range loc.Transitions(start, end) {
+		used = append(used, tx.After)
+		if tx.After.IsDST && !tx.Before.IsDST && zy.DstStart == 0 {
+			zy.DstStart = tx.When
+		}
+		if tx.Before.IsDST && !tx.After.IsDST && zy.DstEnd == 0 {
+			zy.DstEnd = tx.When
+		}
 	}
 
-	zoneInfo.Extend = data.Extend()
-	tzi[zone] = zoneInfo
+	var haveStd, haveDst bool
+	for _, tt := range used {
+		if !tt.IsDST && !haveStd {
+			zy.Std, haveStd = tt, true
+		} else if tt.IsDST && !haveDst {
+			zy.Dst, haveDst = tt, true
+		}
+	}
+	zy.HasDst = haveStd && haveDst
+	zy.PermanentDst = haveDst && !haveStd
+	if zy.PermanentDst {
+		// The standard time is never in force; the footer still names it.
+		if tz, err := tzposix.Parse(loc.Extend()); err == nil {
+			zy.Std = rfc9636.TimeType{Name: tz.StdName, Offset: tz.StdOffset}
+		}
+	}
+	if !zy.HasDst {
+		zy.DstStart, zy.DstEnd = 0, 0
+	}
+	return zy
+}
+
+// DstPeriod describes when daylight saving time starts and ends in the zone's year
+// using the local wall clock time in force just before each change.
+func (zy ZoneYear) DstPeriod() string {
+	if !zy.HasDst {
+		return ""
+	}
+	at := func(sec int64, tt rfc9636.TimeType) string {
+		if sec == 0 {
+			return "never"
+		}
+		return time.Unix(sec, 0).In(time.FixedZone(tt.Name, tt.Offset)).Format("2006-01-02 15:04:05 MST")
+	}
+	period := fmt.Sprintf("DST %d: Starts %s, Ends %s", zy.Year, at(zy.DstStart, zy.Std), at(zy.DstEnd, zy.Dst))
+	if zy.Southern() {
+		period += " (spans the new year)"
+	}
+	return period
+}
+
+// AliasesWithMethods returns the aliases annotated with how each link was established
+// and whether they are deprecated.
+func (zoneInfo TzInfoType) AliasesWithMethods() []string {
+	aliases := make([]string, 0, len(zoneInfo.Aliases))
+	for _, alias := range zoneInfo.Aliases {
+		annotation := zoneInfo.LinkMethods[alias]
+		if status := zoneInfo.AliasDeprecations[alias]; status.Deprecated {
+			annotation += ", deprecated"
+		} else if status.Unknown {
+			annotation += ", deprecation unknown"
+		}
+		aliases = append(aliases, alias+"("+annotation+")")
+	}
+	return aliases
 }
 
 func NewTzInfo() TzInfoType {
 	return TzInfoType{
-		Aliases: make([]string, 0),
-		Offsets: make([]TzZoneType, 0, 2),
-		Extend:  "",
+		Aliases:     make([]string, 0),
+		LinkMethods: make(map[string]string),
+		Offsets:     make([]TzZoneType, 0, 2),
+		Extend:      "",
+	}
+}
+
+// ZoneTransitions returns the transitions selected by the --transitions, --from and --to flags.
+func ZoneTransitions(loc *rfc9636.Location) []SchedulerTransition {
+	if loc == nil {
+		return nil
+	}
+	var txs []rfc9636.Transition
+	if !transitionsTo.IsZero() {
+		txs = loc.Transitions(transitionsFrom.Unix(), transitionsTo.Unix())
+	} else if numTransitions > 0 {
+		txs = loc.NextTransitions(transitionsFrom.Unix(), numTransitions)
+	}
+	sts := make([]SchedulerTransition, 0, len(txs))
+	for _, tx := range txs {
+		sts = append(sts, SchedulerTransition{
+			When:         time.Unix(tx.When, 0).UTC().Format(time.RFC3339),
+			Unix:         tx.When,
+			OffsetBefore: tx.Before.Offset,
+			OffsetAfter:  tx.After.Offset,
+			Abbr:         tx.After.Name,
+			IsDst:        tx.After.IsDST,
+		})
+	}
+	return sts
+}
+
+// ParseTimestamp accepts RFC 3339 timestamps, the same without a zone (taken as UTC),
+// plain dates and "@" followed by Unix seconds.
+func ParseTimestamp(value string) (time.Time, error) {
+	if unix, found := strings.CutPrefix(value, "@"); found {
+		sec, err := strconv.ParseInt(unix, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid Unix timestamp %q: %w", value, err)
+		}
+		return time.Unix(sec, 0).UTC(), nil
 	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC 3339, YYYY-MM-DD or @unixseconds", value)
 }
 
 func SupportsDST(numOffsets int) string {
@@ -143,18 +413,65 @@ func NewSchedulerJson(name, std, dst string, dstFlag bool, aliases []string, rul
 	}
 }
 
+// SetPosixTZ fills in the numeric and structured fields from the zone's POSIX TZ footer.
+func (zj *SchedulerJson) SetPosixTZ(tz tzposix.TZ) {
+	zj.PosixTZ = tz.Raw
+	zj.StdAbbr = tz.StdName
+	zj.StdOffset = tz.StdOffset
+	if !tz.HasDST() {
+		return
+	}
+	dstOffset, saving := tz.DstOffset, tz.Saving()
+	zj.DstAbbr = tz.DstName
+	zj.DstOffset = &dstOffset
+	zj.DstSaving = &saving
+	zj.DstStart = NewSchedulerRule(tz.Start)
+	zj.DstEnd = NewSchedulerRule(tz.End)
+}
+
+func NewSchedulerRule(r tzposix.Rule) *SchedulerRule {
+	sr := &SchedulerRule{Kind: r.Kind.String(), Time: r.Time}
+	if r.Kind == tzposix.RuleMonthWeekDay {
+		sr.Month, sr.Week, sr.Weekday = r.Month, r.Week, r.Day
+	} else {
+		sr.Day = r.Day
+	}
+	return sr
+}
+
 func GenerateJson(zones []string) {
+	if SourceData == nil {
+		slog.Warn("No tzdata.zi or --source file with zones and rules, SourceRules are left out")
+	}
 	for _, name := range zones {
 		if zone, exist := TzInfos[name]; exist {
 			std, dst, rules, err := tzposix.DecodeTZ(zone.Extend)
 			if err != nil {
 				slog.Error("DecodeTZ failure", "TZ", zone.Extend, "error", err)
 			}
+			tz, err := tzposix.Parse(zone.Extend)
+			if err != nil {
+				slog.Error("Parse failure", "TZ", zone.Extend, "error", err)
+			}
+			zj := NewSchedulerJson("", std, dst, len(zone.Offsets) > 1, zone.Aliases, rules)
+			zj.SetPosixTZ(tz)
+			zj.PermanentDst = zone.Year.PermanentDst
+			zj.AliasMethods = zone.LinkMethods
+			zj.AliasDeprecations = zone.AliasDeprecations
+			zj.Deprecated, zj.ReplacedBy = zone.Deprecated, zone.ReplacedBy
+			zj.SetGeo(zone.Geo)
+			if SourceData != nil {
+				if source, ok := SourceZone(SourceData, name); ok {
+					zj.SourceRules = source.RuleNames()
+				}
+			}
+			zj.Variants = zone.Variants
+			zj.Transitions = ZoneTransitions(zone.Location)
+			// The objects format keys the entries by name instead.
 			if jsonFileFormat == "slices" {
-				zj := NewSchedulerJson(name, std, dst, len(zone.Offsets) > 1, zone.Aliases, rules)
+				zj.Name = name
 				SchedulerZoneSlices = append(SchedulerZoneSlices, zj)
 			} else if jsonFileFormat == "objects" {
-				zj := NewSchedulerJson("", std, dst, len(zone.Offsets) > 1, zone.Aliases, rules)
 				SchedulerZoneObjects[name] = zj
 			}
 
@@ -165,12 +482,14 @@ func GenerateJson(zones []string) {
 	var jsonData []byte
 	var err error
 	if jsonFileFormat == "slices" {
-		jsonData, err = json.MarshalIndent(SchedulerZoneSlices, "", "  ") // Use MarshalIndent for pretty print
+		doc := SchedulerDocument{SchemaVersion: SchedulerSchemaVersion, TzdataVersion: TzdataVersion, Zones: SchedulerZoneSlices}
+		jsonData, err = json.MarshalIndent(doc, "", "  ") // Use MarshalIndent for pretty print
 		if err != nil {
 			Fatal("Error marshaling to JSON ", "error", err)
 		}
 	} else if jsonFileFormat == "objects" {
-		jsonData, err = json.MarshalIndent(SchedulerZoneObjects, "", "  ") // Use MarshalIndent for pretty print
+		doc := SchedulerDocument{SchemaVersion: SchedulerSchemaVersion, TzdataVersion: TzdataVersion, Zones: SchedulerZoneObjects}
+		jsonData, err = json.MarshalIndent(doc, "", "  ") // Use MarshalIndent for pretty print
 		if err != nil {
 			Fatal("Error marshaling to JSON ", "error", err)
 		}
@@ -210,9 +529,99 @@ func main() {
 	// --json=hulu		hulu
 	// --json		scheduler.json
 	// [nothing]		""
+	pflag.IntVar(&numTransitions, "transitions", 0, "Include the next N transitions of each zone in the scheduler JSON")
+	pflag.Func("from", "Start of the transition window, RFC 3339, YYYY-MM-DD or @unixseconds (default --at)", func(value string) (err error) {
+		transitionsFrom, err = ParseTimestamp(value)
+		return err
+	})
+	pflag.Func("to", "End of the transition window; includes every transition between --from and --to in the scheduler JSON", func(value string) (err error) {
+		transitionsTo, err = ParseTimestamp(value)
+		return err
+	})
+	pflag.Func("at", "Analyze the zones at this instant, RFC 3339, YYYY-MM-DD or @unixseconds (default now)", func(value string) (err error) {
+		analysisTime, err = ParseTimestamp(value)
+		return err
+	})
+	pflag.Func("year", "Analyze the zones in this year (default the current year)", func(value string) error {
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid year %q", value)
+		}
+		analysisTime = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		return nil
+	})
+	pflag.Func("zoneinfo", "Scan this zoneinfo directory instead of the system ones, may be repeated", func(value string) error {
+		if !zoneDirsFromFlag {
+			ZoneDirs, zoneDirsFromFlag = nil, true
+		}
+		ZoneDirs = append(ZoneDirs, strings.TrimRight(value, "/")+"/")
+		return nil
+	})
+	pflag.Func("format", "Output format of commands: text, csv or json", func(value string) error {
+		if !slices.Contains([]string{"text", "csv", "json"}, value) {
+			return fmt.Errorf("unknown format %q, expected text, csv or json", value)
+		}
+		outputFormat = value
+		return nil
+	})
+	pflag.Func("policy", "Resolve skipped and repeated local times: earlier, later, shift-forward or reject", func(value string) error {
+		policy, exists := map[string]rfc9636.Policy{
+			"earlier":       rfc9636.PolicyEarlier,
+			"later":         rfc9636.PolicyLater,
+			"shift-forward": rfc9636.PolicyShiftForward,
+			"reject":        rfc9636.PolicyReject,
+		}[value]
+		if !exists {
+			return fmt.Errorf("unknown policy %q, expected earlier, later, shift-forward or reject", value)
+		}
+		wallClockPolicy = &policy
+		return nil
+	})
+	pflag.BoolVar(&hideDeprecated, "hide-deprecated", false, "Leave deprecated zones and aliases out of the lists")
+	pflag.Func("country", "Only list zones used in this ISO 3166 country, may be repeated or comma separated", func(value string) error {
+		for _, code := range strings.Split(value, ",") {
+			countryFilter = append(countryFilter, strings.ToUpper(strings.TrimSpace(code)))
+		}
+		return nil
+	})
+	pflag.BoolVar(&includeRight, "include-right", false, "Attach the right/ and posix/ copies of each zone, with its leap seconds, to the zone")
+	pflag.StringSliceVar(&includeGlobs, "include", nil, "Also list the files and directories matching these globs as zones, e.g. posixrules")
+	pflag.StringSliceVar(&excludeGlobs, "exclude", nil, "Leave the files and directories matching these globs out, e.g. Factory or Etc")
+	pflag.StringSliceVar(&sourceFiles, "source", nil, "Read zones, rules and links from these tzdata source files instead of tzdata.zi")
+	pflag.Func("bloat", "TZif output of compile, mkzone and rewrite: slim relies on the footer, fat adds redundant data", setBloat)
+	pflag.Func("range", "Only compile or rewrite transitions in this zic -r range, [@lo][/@hi] in unix seconds", setRange)
+	pflag.FuncP("cutoff", "c", "Years dump covers, the zdump -c option: [loyear,]hiyear (default -500,2500)", setCutoff)
+	pflag.StringVarP(&outputDir, "output", "o", outputDir, "Directory compile and rewrite write to, or the file mkzone writes")
+	pflag.StringVar(&mkzoneTZ, "tz", "", "POSIX TZ string the mkzone command writes a TZif file for")
+	printSchema := pflag.Bool("json-schema", false, "Print the JSON Schema of the scheduler JSON file and exit")
 
 	pflag.Parse()
 
+	if *printSchema {
+		os.Stdout.Write(SchedulerSchema)
+		return
+	}
+	for _, globs := range [][]string{includeGlobs, excludeGlobs} {
+		if err := validateGlobs(globs); err != nil {
+			Fatal("Invalid --include or --exclude pattern", "error", err)
+		}
+	}
+	if transitionsFrom.IsZero() {
+		transitionsFrom = analysisTime
+	}
+	if !transitionsTo.IsZero() && !transitionsTo.After(transitionsFrom) {
+		Fatal("--to must be after --from", "from", transitionsFrom, "to", transitionsTo)
+	}
+
+	if pflag.NArg() > 0 {
+		command, exists := Commands[pflag.Arg(0)]
+		if !exists {
+			Fatal("Unknown command", "command", pflag.Arg(0))
+		}
+		command(pflag.Args()[1:])
+		return
+	}
+
 	numAliases := 0
 	zones, keylen := GetOsTimeZones()
 	if len(SchedulerFilename) > 0 {
@@ -221,7 +630,7 @@ func main() {
 	}
 
 	keylen += 3 // for output spacing
-	slog.Info("Statistics", "numKeys", len(zones), "keylen", keylen)
+	slog.Info("Statistics", "numKeys", len(zones), "keylen", keylen, "tzdata", TzdataVersion)
 	for _, name := range zones {
 		zone, exist := TzInfos[name]
 		if exist {
@@ -233,49 +642,65 @@ func main() {
 				slog.Error("HumanReadableTZ failure", "extend", zone.Extend, "error", err)
 			}
 
-			fmt.Printf("%-*s DST: %-3s %+v Extend %s\n", keylen, name, SupportsDST(len(zone.Offsets)), zone.Aliases, zone.Extend)
+			dstFlag := SupportsDST(len(zone.Offsets))
+			if zone.Year.PermanentDst {
+				dstFlag = "permanent"
+			}
+			fmt.Printf("%-*s DST: %-3s %+v Extend %s\n", keylen, name, dstFlag, zone.AliasesWithMethods(), zone.Extend)
 			if len(description) != 0 {
 				fmt.Println(description)
 			}
+			if period := zone.Year.DstPeriod(); period != "" {
+				fmt.Println(period)
+			}
+			if len(zone.Geo.Countries) > 0 {
+				fmt.Printf("Countries: %s", CountryList(zone.Geo.Countries))
+				if zone.Geo.Coordinates != "" {
+					fmt.Printf("; Coordinates: %s", zone.Geo.Coordinates)
+				}
+				if zone.Geo.Comment != "" {
+					fmt.Printf("; %s", zone.Geo.Comment)
+				}
+				fmt.Println()
+			}
+			if variants := zone.VariantList(); variants != "" {
+				fmt.Printf("Variants: %s\n", variants)
+			}
+			if zone.Deprecated {
+				if zone.ReplacedBy != "" {
+					fmt.Printf("Deprecated: use %s\n", zone.ReplacedBy)
+				} else {
+					fmt.Println("Deprecated: kept for backward compatibility")
+				}
+			}
 		} else {
 			fmt.Printf("Missing zone %s\n", name)
 		}
 		numAliases += len(zone.Aliases)
 	}
-	slog.Info("Statistics", "zoneinfos", len(zones), "aliases", numAliases, "total", len(zones)+numAliases)
+	slog.Info("Statistics", "zoneinfos", len(zones), "aliases", numAliases, "total", len(zones)+numAliases, "tzdata", TzdataVersion)
 }
 
-// UsesDST checks if a given location observes Daylight Saving Time by comparing offsets.
-
-func UsesDST(timezone string) (bool, string, string, int, error) {
-	year := time.Now().Year()
-	loc, err := time.LoadLocation(timezone)
-	if err != nil {
-		return false, "", "", year, err
-	}
-
-	// Check offset on a winter date (Jan 1) and a summer date (Jul 1)
-	winterTime := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
-	summerTime := time.Date(year, time.July, 1, 0, 0, 0, 0, loc)
-
-	xst, winterOffset := winterTime.Zone()
-	xdt, summerOffset := summerTime.Zone()
+// UsesDST checks if a given location observes Daylight Saving Time using the isDST flags
+// of the local time types in force during the year.
 
-	// If the offsets are different, the timezone uses DST rules.
-	return winterOffset != summerOffset, xst, xdt, year, nil
+func UsesDST(loc *rfc9636.Location, year int) (bool, string, string, int) {
+	zy := ClassifyYear(loc, year)
+	return zy.HasDst, zy.Std.Name, zy.Dst.Name, year
 }
 
 func GetOsTimeZones() ([]string, int) {
-	var zoneDirs = []string{
-		// Update path according to your OS
-		"/usr/share/zoneinfo/",
-		"/usr/share/lib/zoneinfo/",
-		"/usr/lib/locale/TZ/",
-	}
-
-	for _, zd := range zoneDirs {
+	for _, zd := range ZoneDirs {
 		walkTzDir(zd)
+		ResolveLinks(zd)
+		if includeRight {
+			walkVariantDirs(zd)
+		}
+		LoadZoneTabs(zd)
 	}
+	TzInfos.MarkDeprecated()
+	TzInfos.FilterCountries()
+	DetectTzdataVersions()
 
 	zones := make([]string, 0, len(TzInfos))
 	keylen := 0
@@ -290,6 +715,54 @@ func GetOsTimeZones() ([]string, int) {
 	return zones, keylen
 }
 
+// LoadZones returns the named zones from ZoneDirs, or every zone found there when no names are given.
+func LoadZones(names []string) []*rfc9636.Location {
+	if len(names) == 0 {
+		zones, _ := GetOsTimeZones()
+		locs := make([]*rfc9636.Location, 0, len(zones))
+		for _, name := range zones {
+			locs = append(locs, TzInfos[name].Location)
+		}
+		return locs
+	}
+	locs := make([]*rfc9636.Location, 0, len(names))
+	for _, name := range names {
+		loc, err := rfc9636.LoadLocation(name, ZoneDirs)
+		if err != nil {
+			Fatal("Could not load zone", "zone", name, "error", err)
+		}
+		locs = append(locs, loc)
+	}
+	return locs
+}
+
+// LoadZoneFile returns the zone in the TZif file at path or, when there is no such file,
+// the zone of that name from ZoneDirs. A file inside a zone directory keeps its zone name.
+func LoadZoneFile(path string) *rfc9636.Location {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return LoadZones([]string{path})[0]
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		Fatal("Could not read zone file", "path", path, "error", err)
+	}
+	name := filepath.Base(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		for _, zd := range ZoneDirs {
+			if rel, ok := strings.CutPrefix(abs, filepath.Clean(zd)+"/"); ok {
+				name = rel
+				break
+			}
+		}
+	}
+	loc, err := rfc9636.LoadLocationFromTZData(name, data)
+	if err != nil {
+		Fatal("Could not load zone file", "path", path, "error", err)
+	}
+	return loc
+}
+
 func walkTzDir(path string) {
 	dirInfos, err := os.ReadDir(path)
 	if err != nil {
@@ -298,29 +771,50 @@ func walkTzDir(path string) {
 	}
 
 	// Linux Convention
-	//   The zoneinfo names are capitalized.  We can ignore directories and files that do not follow
-	//   that convention for now. We might need an exception list in the future if we want to allow
-	//   localtime and posixrules zoneinfo files to be processed as well
+	//   The zoneinfo directory names are capitalized.  We ignore directories that do not follow
+	//   that convention unless --include names them. Files such as localtime, posixrules and
+	//   the .tab tables are classified by SpecialFiles instead.
 
 	for _, info := range dirInfos {
-		if info.IsDir() && info.Name() != strings.ToUpper(info.Name()[:1])+info.Name()[1:] {
-			Trace("Skipping directory because name is not capitalized ", "filename", info.Name())
+		newPath := path + "/" + info.Name()
+		parts := strings.Split(newPath, "//")
+		if len(parts) != 2 {
 			continue
 		}
 
-		newPath := path + "/" + info.Name()
+		// The right/ and posix/ subtrees repeat the zones, see walkVariantDirs.
+		if isVariantDir(parts[1]) {
+			Trace("Skipping variant subtree", "filename", info.Name())
+			continue
+		}
+		if info.IsDir() {
+			if excluded(parts[1]) {
+				Trace("Skipping excluded directory", "filename", parts[1])
+				continue
+			}
+			if info.Name() != strings.ToUpper(info.Name()[:1])+info.Name()[1:] && !matchesAny(includeGlobs, parts[1]) {
+				Trace("Skipping directory because name is not capitalized ", "filename", info.Name())
+				continue
+			}
+		}
 
 		if info.IsDir() {
 			walkTzDir(newPath)
 		} else {
-			parts := strings.Split(newPath, "//")
-			if len(parts) != 2 {
+			if walk, special := walkFile(parts[1]); !walk {
+				if special != nil {
+					Trace("Skipping special file", "filename", parts[1], "meaning", special.Meaning)
+				} else {
+					Trace("Skipping excluded file", "filename", parts[1])
+				}
 				continue
 			}
 			if zoneInfo, err := rfc9636.LoadLocation(parts[1], []string{parts[0]}); err == nil {
 				slog.Debug("dump of zoneinfo", "timezone", parts[1])
 				if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
-					rfc9636.DumpLocation(zoneInfo)
+					if end, ok := zoneInfo.TableEnd(); ok {
+						rfc9636.DumpLocation(os.Stdout, zoneInfo, -1<<63, end+1)
+					}
 				}
 
 				if info.Type()&os.ModeSymlink != 0 {
@@ -340,10 +834,15 @@ func walkTzDir(path string) {
 						slog.Error("Could not extract timezone alias", "path", resolvedPath)
 						continue
 					}
+					if walk, _ := walkFile(atz); !walk {
+						Trace("Skipping link to a file that is not listed", "link", parts[1], "target", atz)
+						continue
+					}
 					slog.Debug("Timezone has alias", "timezone", atz, "alias", parts[1])
-					TzInfos.AddZoneAlias(atz, parts[1])
+					TzInfos.AddZoneAlias(atz, parts[1], LinkSymlink)
 				} else {
 					TzInfos.Add(parts[1], zoneInfo)
+					recordZoneFile(parts[0], parts[1], newPath)
 				}
 
 			} else {
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+	"github.com/tzlist/rfc9636"
+)
+
+// mkzoneTZ, set by --tz, is the POSIX TZ string mkzone turns into a TZif file.
+var mkzoneTZ string
+
+// MkzoneCommand writes a TZif file for the POSIX TZ string given with --tz. With a zone
+// name argument the file is written below outputDir like compile does, otherwise to the
+// --output path itself. The transitions between --from and --to, if --to is given, are
+// written to the transition table as well.
+func MkzoneCommand(args []string) {
+	if mkzoneTZ == "" || len(args) > 1 || len(args) == 0 && !pflag.Lookup("output").Changed {
+		Fatal("Usage: tzlist mkzone --tz <POSIX TZ> [--from <time> --to <time>] (-o <file> | <zone name>)")
+	}
+	name, path := filepath.Base(outputDir), outputDir
+	if len(args) == 1 {
+		name, path = args[0], filepath.Join(outputDir, args[0])
+	}
+
+	from, to := int64(0), int64(0)
+	if !transitionsTo.IsZero() {
+		from, to = transitionsFrom.Unix(), transitionsTo.Unix()
+	}
+	loc, err := rfc9636.LocationFromTZ(name, mkzoneTZ, from, to)
+	if err != nil {
+		Fatal("Invalid POSIX TZ string", "tz", mkzoneTZ, "error", err)
+	}
+	tzif, err := loc.TZData(compileOptions.Fat)
+	if err != nil {
+		Fatal("Error encoding zone", "tz", mkzoneTZ, "error", err)
+	}
+	if _, err := rfc9636.LoadLocationFromTZData(name, tzif); err != nil {
+		Fatal("Encoded zone does not load", "tz", mkzoneTZ, "error", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		Fatal("Error writing zone", "path", path, "error", err)
+	}
+	if err := os.WriteFile(path, tzif, 0o644); err != nil {
+		Fatal("Error writing zone", "path", path, "error", err)
+	}
+	slog.Info("Wrote zone", "path", path, "tz", loc.Extend())
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+	"github.com/tzlist/zic"
+)
+
+// RewriteCommand rewrites TZif files or zones below outputDir, limited to the --range and
+// in the --bloat form like zic -r and -b do, and checks that every lookup inside the range
+// gives what the original file gives.
+func RewriteCommand(args []string) {
+	if len(args) == 0 {
+		Fatal("Usage: tzlist rewrite [--range @lo/@hi] [--bloat slim|fat] <file or zone>...")
+	}
+	lo, hi := compileOptions.Lo, int64(math.MaxInt64)
+	if compileOptions.Hi != zic.NoHi {
+		hi = compileOptions.Hi + 1
+	}
+	// Without an upper bound the footer takes over, so check a while past the table.
+	verifyTo := hi
+	if hi == math.MaxInt64 {
+		verifyTo = time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	}
+
+	for _, arg := range args {
+		loc := LoadZoneFile(arg)
+		out, err := loc.Truncate(lo, hi)
+		if err == nil && !compileOptions.Fat {
+			out, err = out.Slim()
+		}
+		if err != nil {
+			Fatal("Could not rewrite zone", "zone", arg, "error", err)
+		}
+		tzif, err := out.TZData(compileOptions.Fat)
+		if err != nil {
+			Fatal("Error encoding zone", "zone", arg, "error", err)
+		}
+		check, err := rfc9636.LoadLocationFromTZData(loc.Name(), tzif)
+		if err != nil {
+			Fatal("Rewritten zone does not load", "zone", arg, "error", err)
+		}
+		if err := loc.Verify(check, lo, verifyTo); err != nil {
+			Fatal("Rewritten zone differs inside the range", "zone", arg, "error", err)
+		}
+		if err := writeZoneFile(loc.Name(), tzif); err != nil {
+			Fatal("Error writing zone", "zone", loc.Name(), "error", err)
+		}
+		slog.Info("Rewrote zone", "zone", loc.Name(), "directory", outputDir, "bytes", len(tzif))
+	}
+}
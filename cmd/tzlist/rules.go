@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/tzlist/tzsource"
+)
+
+// LinkSource is the method of aliases declared by the --source files.
+const LinkSource = "source"
+
+// sourceFiles, set by --source, are tzdata source files such as northamerica and backward.
+// They take the place of the tzdata.zi and backward files of the zone directories.
+var sourceFiles []string
+
+// SourceData is the zic input the zones were read with, for their rule names and history.
+// It is nil when only a backward file was found, which has links but no zones or rules.
+var SourceData *tzsource.Data
+
+// readSource parses the --source files, or else tzdata.zi or backward in root, and returns
+// the alias method of its links. The data of backward has links only, enough to classify
+// aliases but not to look up zones or rules.
+func readSource(root string) (*tzsource.Data, string, error) {
+	if len(sourceFiles) > 0 {
+		if SourceData != nil {
+			return SourceData, LinkSource, nil
+		}
+		data := &tzsource.Data{}
+		for _, path := range sourceFiles {
+			if err := data.ReadFile(path); err != nil {
+				return nil, "", err
+			}
+		}
+		return data, LinkSource, nil
+	}
+	var firstErr error
+	for _, source := range []struct{ file, method string }{{"tzdata.zi", LinkTzdataZi}, {"backward", LinkBackward}} {
+		data, err := tzsource.ReadFile(root + source.file)
+		if err == nil {
+			return data, source.method, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		Trace("Source file is not usable", "path", root+source.file, "error", err)
+	}
+	return nil, "", firstErr
+}
+
+// loadSource returns SourceData, reading it from the first zone directory whose source
+// has zones.
+func loadSource() *tzsource.Data {
+	for _, zd := range ZoneDirs {
+		if SourceData != nil {
+			break
+		}
+		if data, _, err := readSource(zd); err == nil && len(data.Zones) > 0 {
+			SourceData = data
+		}
+	}
+	if SourceData == nil {
+		Fatal("No tzdata.zi or --source file with zones and rules, backward only has links")
+	}
+	return SourceData
+}
+
+// SourceZone returns the source zone named zone or, when it is a link, the zone it leads to.
+func SourceZone(data *tzsource.Data, name string) (tzsource.Zone, bool) {
+	for range len(data.Links) + 1 {
+		if zone, ok := data.Zone(name); ok {
+			return zone, true
+		}
+		index := slices.IndexFunc(data.Links, func(l tzsource.Link) bool { return l.Name == name })
+		if index < 0 {
+			break
+		}
+		name = data.Links[index].Target
+	}
+	return tzsource.Zone{}, false
+}
+
+// ZoneSource is the source of one zone as reported by the rules command.
+type ZoneSource struct {
+	Zone  string              `json:"Zone"`
+	Name  string              `json:"Name"` // the requested name, a link when it differs from Zone
+	Lines []string            `json:"Lines"`
+	Rules map[string][]string `json:"Rules"`
+}
+
+// RulesCommand prints the zone lines of the named zones and the rules they use.
+// Read from tzdata.zi the rule names are the shortened ones, which the output points out.
+func RulesCommand(args []string) {
+	if len(args) == 0 {
+		Fatal("Usage: tzlist rules <zone>...")
+	}
+	data := loadSource()
+
+	var sources []ZoneSource
+	for _, name := range args {
+		zone, ok := SourceZone(data, name)
+		if !ok {
+			Fatal("Zone is not in the source", "zone", name)
+		}
+		zs := ZoneSource{Zone: zone.Name, Name: name, Rules: make(map[string][]string)}
+		for _, zl := range zone.Lines {
+			zs.Lines = append(zs.Lines, zl.String())
+		}
+		for _, ruleName := range zone.RuleNames() {
+			for _, rule := range data.Rules[ruleName] {
+				zs.Rules[ruleName] = append(zs.Rules[ruleName], rule.String())
+			}
+		}
+		sources = append(sources, zs)
+	}
+
+	if data.Compact() && outputFormat != "text" {
+		slog.Warn("Rule names are shortened as in tzdata.zi, use --source with the tzdata source files for the full names")
+	}
+	if writeZoneSources(sources) {
+		return
+	}
+	if data.Compact() {
+		fmt.Printf("# Rule names are shortened as in tzdata.zi %s; use --source with the tzdata source files for the full names\n\n", data.Version)
+	}
+	for _, zs := range sources {
+		if zs.Name != zs.Zone {
+			fmt.Printf("%s (link to %s)\n", zs.Name, zs.Zone)
+		}
+		fmt.Printf("Zone\t%s\n", zs.Zone)
+		for _, line := range zs.Lines {
+			fmt.Printf("\t%s\n", line)
+		}
+		for _, ruleName := range slices.Sorted(maps.Keys(zs.Rules)) {
+			for _, line := range zs.Rules[ruleName] {
+				fmt.Println(line)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// writeZoneSources prints sources as JSON or CSV when --format selects one of them,
+// and reports whether it did.
+func writeZoneSources(sources []ZoneSource) bool {
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(sources, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"zone", "name", "kind", "line"})
+		for _, zs := range sources {
+			for _, line := range zs.Lines {
+				w.Write([]string{zs.Zone, zs.Name, "zone", line})
+			}
+			for _, ruleName := range slices.Sorted(maps.Keys(zs.Rules)) {
+				for _, line := range zs.Rules[ruleName] {
+					w.Write([]string{zs.Zone, zs.Name, "rule", line})
+				}
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// SpecialFile describes files of a zoneinfo directory that are not zones, or zones unlike the others.
+type SpecialFile struct {
+	Pattern         string `json:"Pattern"`         // path.Match glob on the name relative to the zoneinfo directory
+	TZif            bool   `json:"TZif"`            // the file holds TZif data
+	ListedByDefault bool   `json:"ListedByDefault"` // a TZif file that is listed as a zone unless excluded
+	Meaning         string `json:"Meaning"`
+}
+
+// SpecialFiles are the files walkTzDir classifies before reading them. Files that are not
+// TZif data are never read as zones, the others only when ListedByDefault or given with --include.
+var SpecialFiles = []SpecialFile{
+	{"posixrules", true, false, "Rules for POSIX TZ strings that give no DST rules, such as EST5EDT, usually a link to America/New_York"},
+	{"localtime", true, false, "The local zone of the system, usually a link to /etc/localtime"},
+	{"Factory", true, true, "Placeholder zone of installations whose zone is not set yet, abbreviated -00"},
+	{"*.tab", false, false, "Zone and country tables such as zone1970.tab and iso3166.tab"},
+	{"tzdata.zi", false, false, "Compact zic input of the whole database with its version"},
+	{"leapseconds", false, false, "Leap second table in zic input format, see the leapseconds command"},
+	{"leap-seconds.list", false, false, "NIST leap second table with its hash and expiration, see the leapseconds command"},
+	{"+VERSION", false, false, "The tzdata version"},
+	{"SECURITY", false, false, "How to report security problems in the tz database"},
+}
+
+// includeGlobs and excludeGlobs, set by --include and --exclude, select the files and
+// directories walkTzDir reads, overriding SpecialFiles and the capitalization convention.
+var includeGlobs, excludeGlobs []string
+
+// matchesAny reports whether name matches one of the globs.
+func matchesAny(globs []string, name string) bool {
+	return slices.ContainsFunc(globs, func(glob string) bool {
+		matched, _ := path.Match(glob, name)
+		return matched
+	})
+}
+
+// excluded reports whether --exclude matches name or one of its directories.
+func excluded(name string) bool {
+	for ; name != "." && name != "/"; name = path.Dir(name) {
+		if matchesAny(excludeGlobs, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifySpecial returns the SpecialFiles entry that name, relative to the zoneinfo directory, matches.
+func classifySpecial(name string) (SpecialFile, bool) {
+	for _, special := range SpecialFiles {
+		if matched, _ := path.Match(special.Pattern, name); matched {
+			return special, true
+		}
+	}
+	return SpecialFile{}, false
+}
+
+// validateGlobs checks the --include and --exclude patterns.
+func validateGlobs(globs []string) error {
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// walkFile reports whether the file name, relative to the zoneinfo directory, is read as a
+// zone, and the SpecialFiles entry it matches. --exclude wins over --include.
+func walkFile(name string) (bool, *SpecialFile) {
+	special, isSpecial := classifySpecial(name)
+	switch {
+	case excluded(name):
+		if isSpecial {
+			return false, &special
+		}
+		return false, nil
+	case !isSpecial:
+		return true, nil
+	case !special.TZif:
+		return false, &special
+	default:
+		return special.ListedByDefault || matchesAny(includeGlobs, name), &special
+	}
+}
+
+// FoundSpecialFile is a special file found in a zoneinfo directory.
+type FoundSpecialFile struct {
+	Directory string `json:"Directory"`
+	File      string `json:"File"`
+	Target    string `json:"Target,omitempty"` // the link target of a symbolic link
+	Listed    bool   `json:"Listed"`           // listed as a zone with the current --include and --exclude
+	SpecialFile
+}
+
+// SpecialCommand reports the special files of the zone directories, what they are for and
+// whether they are listed as zones with the current --include and --exclude patterns.
+// Like walkTzDir it looks through the whole tree apart from the variant subtrees.
+func SpecialCommand(args []string) {
+	var found []FoundSpecialFile
+	for _, zd := range ZoneDirs {
+		if _, err := os.Stat(zd); err != nil {
+			Trace("zoneinfo directory is not available", "path", zd)
+			continue
+		}
+		filepath.WalkDir(zd, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			name, err := filepath.Rel(zd, file)
+			if err != nil || name == "." {
+				return nil
+			}
+			if entry.IsDir() {
+				if isVariantDir(name) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			listed, special := walkFile(name)
+			if special == nil {
+				return nil
+			}
+			f := FoundSpecialFile{Directory: zd, File: name, Listed: listed, SpecialFile: *special}
+			if entry.Type()&os.ModeSymlink != 0 {
+				f.Target, _ = os.Readlink(file)
+			}
+			found = append(found, f)
+			return nil
+		})
+	}
+
+	switch outputFormat {
+	case "json":
+		jsonData, err := json.MarshalIndent(found, "", "  ")
+		if err != nil {
+			Fatal("Error marshaling to JSON ", "error", err)
+		}
+		fmt.Println(string(jsonData))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"directory", "file", "target", "tzif", "listed", "meaning"})
+		for _, f := range found {
+			w.Write([]string{f.Directory, f.File, f.Target, strconv.FormatBool(f.TZif), strconv.FormatBool(f.Listed), f.Meaning})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			Fatal("Error writing CSV", "error", err)
+		}
+	default:
+		for _, f := range found {
+			name := filepath.Join(f.Directory, f.File)
+			if f.Target != "" {
+				name += " -> " + f.Target
+			}
+			listed := ""
+			if f.Listed {
+				listed = " (listed as a zone)"
+			}
+			fmt.Printf("%s%s\n    %s\n", name, listed, f.Meaning)
+		}
+	}
+}
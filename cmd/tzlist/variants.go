@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tzlist/rfc9636"
+)
+
+// includeRight, set by --include-right, attaches the zones of the VariantDirs to their base zones.
+var includeRight bool
+
+// VariantDirs are the subtrees of a zoneinfo directory that repeat its zones: posix/ in
+// POSIX time like the main tree, right/ with the leap seconds counted. Some distributions
+// make posix/ a tree of links back to the main tree.
+var VariantDirs = []string{"posix", "right"}
+
+// ZoneVariant is the copy of a zone in one of the VariantDirs.
+type ZoneVariant struct {
+	Name        string `json:"Name"`                  // zone name with the subtree, e.g. right/Europe/Paris
+	LeapSeconds int    `json:"LeapSeconds,omitempty"` // leap second records of the file, none in posix/
+}
+
+// isVariantDir reports whether name, relative to the zoneinfo directory, is one of the VariantDirs.
+func isVariantDir(name string) bool {
+	return slices.Contains(VariantDirs, strings.ToLower(name))
+}
+
+// walkVariantDirs attaches the zones in the VariantDirs of the zoneinfo directory root to
+// the zones of the same name in TzInfos. Files of other names, such as the aliases of
+// right/, are left out since their zones are attached already.
+func walkVariantDirs(root string) {
+	rootReal, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return
+	}
+	for _, variant := range VariantDirs {
+		variantReal, err := filepath.EvalSymlinks(filepath.Join(root, variant))
+		if err != nil {
+			Trace("zoneinfo variant directory is not available", "path", filepath.Join(root, variant))
+			continue
+		}
+		if variantReal == rootReal {
+			// A posix -> . link, as some distributions install it, makes the variant the
+			// main tree itself; walking it would only find the link again.
+			for name, zoneInfo := range TzInfos {
+				if _, err := os.Stat(filepath.Join(root, variant, name)); err == nil {
+					zoneInfo.setVariant(variant, name, 0)
+					TzInfos[name] = zoneInfo
+				}
+			}
+			continue
+		}
+		walkVariantDir(root, variant, "", map[string]bool{rootReal: true, variantReal: true})
+	}
+}
+
+// walkVariantDir attaches the zones below dir of the variant. visited holds the real paths
+// of the directories walked so far, so that links back up the tree do not loop.
+func walkVariantDir(root, variant, dir string, visited map[string]bool) {
+	path := filepath.Join(root, variant, dir)
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		Trace("zoneinfo variant directory is not available", "path", path)
+		return
+	}
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		// Follow links, posix/ may link whole directories back to the main tree.
+		info, err := os.Stat(filepath.Join(path, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			real, err := filepath.EvalSymlinks(filepath.Join(path, entry.Name()))
+			if err != nil || visited[real] {
+				Trace("Variant directory is walked already", "variant", variant+"/"+name)
+				continue
+			}
+			visited[real] = true
+			walkVariantDir(root, variant, name, visited)
+			continue
+		}
+		zoneInfo, exists := TzInfos[name]
+		if !exists {
+			Trace("Variant has no base zone", "variant", variant+"/"+name)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			slog.Error("Could not read zone file", "path", filepath.Join(path, entry.Name()), "error", err)
+			continue
+		}
+		layout := rfc9636.Inspect(data)
+		if len(layout.Problems) > 0 || len(layout.Blocks) == 0 {
+			Trace("File is not a timezone file", "file", filepath.Join(path, entry.Name()))
+			continue
+		}
+		zoneInfo.setVariant(variant, name, len(layout.Blocks[len(layout.Blocks)-1].Leaps))
+		TzInfos[name] = zoneInfo
+	}
+}
+
+// setVariant records the copy of the zone name in variant with its number of leap seconds.
+func (zoneInfo *TzInfoType) setVariant(variant, name string, leapSeconds int) {
+	if zoneInfo.Variants == nil {
+		zoneInfo.Variants = make(map[string]ZoneVariant)
+	}
+	zoneInfo.Variants[variant] = ZoneVariant{Name: variant + "/" + name, LeapSeconds: leapSeconds}
+}
+
+// VariantList returns the variants of the zone for the text listing, e.g.
+// "posix/Europe/Paris, right/Europe/Paris (27 leap seconds)".
+func (zoneInfo TzInfoType) VariantList() string {
+	var list []string
+	for _, variant := range VariantDirs {
+		if v, ok := zoneInfo.Variants[variant]; ok {
+			if v.LeapSeconds > 0 {
+				list = append(list, v.Name+" ("+strconv.Itoa(v.LeapSeconds)+" leap seconds)")
+			} else {
+				list = append(list, v.Name)
+			}
+		}
+	}
+	return strings.Join(list, ", ")
+}
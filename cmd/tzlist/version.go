@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// TzdataVersion is the tzdata release of the first zone directory that reports one.
+var TzdataVersion string
+
+// DetectTzdataVersion returns the tzdata release of the zone directory root, read from
+// tzdata.zi or else +VERSION, and the file it was read from.
+func DetectTzdataVersion(root string) (string, string, error) {
+	root = strings.TrimSuffix(root, "/")
+	for _, name := range []string{"tzdata.zi", "+VERSION"} {
+		f, err := os.Open(root + "/" + name)
+		if err != nil {
+			continue
+		}
+		version := readTzdataVersion(f, name)
+		f.Close()
+		if version != "" {
+			return version, root + "/" + name, nil
+		}
+	}
+	return "", "", errors.New("no tzdata version found in " + root)
+}
+
+// readTzdataVersion reads the "# version" header of tzdata.zi or the contents of +VERSION.
+func readTzdataVersion(r io.Reader, name string) string {
+	if name == "+VERSION" {
+		data, err := io.ReadAll(io.LimitReader(r, 256))
+		if err != nil {
+			return ""
+		}
+		return string(bytes.TrimSpace(data))
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if version, found := strings.CutPrefix(line, "# version "); found {
+			return strings.TrimSpace(version)
+		}
+	}
+	return ""
+}
+
+// DetectTzdataVersions sets TzdataVersion from the first of ZoneDirs that reports a release.
+func DetectTzdataVersions() {
+	for _, zd := range ZoneDirs {
+		version, source, err := DetectTzdataVersion(zd)
+		if err != nil {
+			Trace("tzdata version not found", "root", zd, "error", err)
+			continue
+		}
+		Trace("tzdata version", "version", version, "source", source)
+		if TzdataVersion == "" {
+			TzdataVersion = version
+		}
+	}
+}
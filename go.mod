@@ -2,4 +2,4 @@ module github.com/tzlist
 
 go 1.25.1
 
-require github.com/spf13/pflag v1.0.10 // indirect
+require github.com/spf13/pflag v1.0.10
@@ -0,0 +1,261 @@
+// Package leapseconds reads the leap second tables that accompany the tz database:
+// leap-seconds.list in the NIST format, with its SHA-1 hash and expiration date,
+// and leapseconds, the zic input from which the right/ zones are built.
+// See the comments at the top of each file in the tzdata distribution.
+package leapseconds
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ntpEpoch is 1900-01-01 00:00:00 UTC, the origin of the NTP timestamps of leap-seconds.list, in POSIX time.
+const ntpEpoch = -2208988800
+
+// InitialTAIOffset is TAI - UTC on 1972-01-01, when UTC got its current form, before any leap second.
+const InitialTAIOffset = 10
+
+// A Leap is a leap second as zic and the leap records of TZif files give it.
+type Leap struct {
+	// Time is the POSIX time of the leap: the start of the next day for an inserted
+	// second, the removed second itself for a deleted one.
+	Time int64
+	// Correction is the total of the leap seconds up to and including this one,
+	// so TAI - UTC is InitialTAIOffset + Correction from Time on.
+	Correction int
+}
+
+// Occurrence returns the time of the leap in the leap second counting time scale of
+// the right/ zones, which the leap records of their TZif files hold.
+func (l Leap) Occurrence(previous Leap) int64 {
+	return l.Time + int64(previous.Correction)
+}
+
+// A Table is a leap second table and the dates that come with it.
+type Table struct {
+	Leaps   []Leap
+	Updated int64  // POSIX time of the last update, 0 if unknown
+	Expires int64  // POSIX time after which the table may miss leap seconds, 0 if unknown
+	Hash    string // the SHA-1 hash of leap-seconds.list in hex, verified when read
+}
+
+// Expired reports whether the table no longer covers now.
+func (t *Table) Expired(now time.Time) bool {
+	return t.Expires != 0 && now.Unix() >= t.Expires
+}
+
+// Occurrences returns the leap second times in the time scale of the right/ zones.
+func (t *Table) Occurrences() []int64 {
+	occurrences := make([]int64, len(t.Leaps))
+	for i, leap := range t.Leaps {
+		if i == 0 {
+			occurrences[i] = leap.Occurrence(Leap{})
+		} else {
+			occurrences[i] = leap.Occurrence(t.Leaps[i-1])
+		}
+	}
+	return occurrences
+}
+
+// A Record is a leap second record of TZif data: from Occurrence on, in the time scale of
+// the right/ zones, Correction seconds have been inserted in total.
+type Record struct {
+	Occurrence int64
+	Correction int
+}
+
+// CheckRecords returns an error when the leap second records of TZif data disagree with
+// the table. A last record that repeats the correction of the one before it only marks
+// when the table expires, as zic writes it for an Expires line, and is not compared.
+func (t *Table) CheckRecords(records []Record) error {
+	if n := len(records); n > 1 && records[n-1].Correction == records[n-2].Correction {
+		records = records[:n-1]
+	}
+	occurrences := t.Occurrences()
+	for i, r := range records {
+		if i >= len(t.Leaps) || r.Occurrence != occurrences[i] || r.Correction != t.Leaps[i].Correction {
+			return fmt.Errorf("record %d at %d with correction %d is not in the table", i, r.Occurrence, r.Correction)
+		}
+	}
+	if len(records) < len(t.Leaps) {
+		return fmt.Errorf("%d records, the table has %d leap seconds", len(records), len(t.Leaps))
+	}
+	return nil
+}
+
+// ReadList reads leap-seconds.list from path.
+func ReadList(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseList(f)
+}
+
+// ParseList parses leap-seconds.list: lines of an NTP timestamp and the TAI - UTC from then
+// on, and the "#$" update, "#@" expiration and "#h" hash comment lines. It fails when the
+// hash over the timestamps and offsets is missing or does not match.
+func ParseList(r io.Reader) (*Table, error) {
+	t := &Table{}
+	var hashed strings.Builder
+	var hash string
+	offset := InitialTAIOffset
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#$"), strings.HasPrefix(line, "#@"):
+			fields := strings.Fields(line[2:])
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("line %d: missing timestamp", lineNo)
+			}
+			ntp, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			hashed.WriteString(fields[0])
+			if line[1] == '$' {
+				t.Updated = ntp + ntpEpoch
+			} else {
+				t.Expires = ntp + ntpEpoch
+			}
+		case strings.HasPrefix(line, "#h"):
+			// Five 32-bit words, from which some versions drop leading zeros.
+			for _, word := range strings.Fields(line[2:]) {
+				hash += strings.Repeat("0", max(0, 8-len(word))) + strings.ToLower(word)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			data, _, _ := strings.Cut(line, "#")
+			fields := strings.Fields(data)
+			if len(fields) == 0 {
+				continue
+			}
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: expected a timestamp and an offset", lineNo)
+			}
+			ntp, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			tai, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			hashed.WriteString(fields[0] + fields[1])
+			if tai != offset {
+				leap := Leap{Time: ntp + ntpEpoch, Correction: tai - InitialTAIOffset}
+				if tai < offset {
+					leap.Time-- // the last second of the day before is left out
+				}
+				t.Leaps = append(t.Leaps, leap)
+				offset = tai
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if hash == "" {
+		return nil, fmt.Errorf("no hash line")
+	}
+	sum := sha1.Sum([]byte(hashed.String()))
+	if computed := hex.EncodeToString(sum[:]); computed != hash {
+		return nil, fmt.Errorf("hash %s does not match the contents, which hash to %s", hash, computed)
+	}
+	t.Hash = hash
+	return t, nil
+}
+
+// ReadLeapseconds reads the zic leapseconds file from path.
+func ReadLeapseconds(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLeapseconds(f)
+}
+
+// ParseLeapseconds parses the Leap and Expires lines of the zic leapseconds file, such as
+// "Leap 2016 Dec 31 23:59:60 + S". The Expires line may be commented out, and the
+// "#updated" and "#expires" comments give the dates in POSIX time.
+func ParseLeapseconds(r io.Reader) (*Table, error) {
+	t := &Table{}
+	correction := 0
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "#updated", "#expires":
+			sec, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if fields[0] == "#updated" {
+				t.Updated = sec
+			} else {
+				t.Expires = sec
+			}
+		case "Expires", "#Expires":
+			if len(fields) < 5 {
+				return nil, fmt.Errorf("line %d: expected year, month, day and time", lineNo)
+			}
+			sec, err := parseDate(fields[1:5])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if t.Expires == 0 || fields[0] == "Expires" {
+				t.Expires = sec
+			}
+		case "Leap":
+			if len(fields) < 7 {
+				return nil, fmt.Errorf("line %d: expected year, month, day, time, correction and R/S", lineNo)
+			}
+			sec, err := parseDate(fields[1:5])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			switch fields[5] {
+			case "+":
+				correction++
+			case "-":
+				correction--
+			default:
+				return nil, fmt.Errorf("line %d: correction %q is not + or -", lineNo, fields[5])
+			}
+			t.Leaps = append(t.Leaps, Leap{Time: sec, Correction: correction})
+		}
+	}
+	return t, scanner.Err()
+}
+
+// parseDate returns the POSIX time of year, month name, day and hh:mm:ss, where 23:59:60
+// is the start of the next day.
+func parseDate(fields []string) (int64, error) {
+	d, err := time.Parse("2006 Jan 2", strings.Join(fields[:3], " "))
+	if err != nil {
+		return 0, err
+	}
+	var h, m, s int
+	if _, err := fmt.Sscanf(fields[3], "%d:%d:%d", &h, &m, &s); err != nil {
+		return 0, fmt.Errorf("invalid time %q", fields[3])
+	}
+	return d.Unix() + int64(h*3600+m*60+s), nil
+}
@@ -0,0 +1,137 @@
+package leapseconds
+
+import (
+	"encoding/binary"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tzlist/rfc9636"
+)
+
+const list = "# leap-seconds.list sample\n" +
+	"#$\t3960835200\n" +
+	"#@\t3991593600\n" +
+	"2272060800\t10\t# 1 Jan 1972\n" +
+	"2287785600\t11\t# 1 Jul 1972\n" +
+	"2303683200\t12\t# 1 Jan 1973\n" +
+	"#h\t2bb8744 05934785 7040be45 616b5dfe 6348ed4b\n"
+
+var want = []Leap{{78796800, 1}, {94694400, 2}}
+
+func TestParseList(t *testing.T) {
+	table, err := ParseList(strings.NewReader(list))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !slices.Equal(table.Leaps, want) {
+		t.Errorf("got %v, want %v", table.Leaps, want)
+	}
+	if table.Updated != 1751846400 || table.Expires != 1782604800 {
+		t.Errorf("got updated %d expires %d, want 1751846400 1782604800", table.Updated, table.Expires)
+	}
+	if table.Hash != "02bb8744059347857040be45616b5dfe6348ed4b" {
+		t.Errorf("got hash %s", table.Hash)
+	}
+	if !table.Expired(time.Unix(1782604800, 0)) || table.Expired(time.Unix(1782604799, 0)) {
+		t.Errorf("Expired is wrong at the expiration time")
+	}
+	if got := table.Occurrences(); !slices.Equal(got, []int64{78796800, 94694401}) {
+		t.Errorf("got occurrences %v, want [78796800 94694401]", got)
+	}
+
+	for name, bad := range map[string]string{
+		"tampered": strings.Replace(list, "2303683200\t12", "2303683200\t13", 1),
+		"no hash":  strings.Replace(list, "#h", "# ", 1),
+		"no field": strings.Replace(list, "2287785600\t11", "2287785600", 1),
+	} {
+		if _, err := ParseList(strings.NewReader(bad)); err == nil {
+			t.Errorf("%s: got nil, want an error", name)
+		}
+	}
+}
+
+func TestParseLeapseconds(t *testing.T) {
+	const leapseconds = "# leapseconds sample\n" +
+		"Leap\t1972\tJun\t30\t23:59:60\t+\tS\n" +
+		"Leap\t1972\tDec\t31\t23:59:60\t+\tS\n" +
+		"#Expires 2026\tJun\t28\t00:00:00\n" +
+		"#updated 1751846400 (2025-07-07 00:00:00 UTC)\n"
+	table, err := ParseLeapseconds(strings.NewReader(leapseconds))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !slices.Equal(table.Leaps, want) {
+		t.Errorf("got %v, want %v", table.Leaps, want)
+	}
+	if table.Updated != 1751846400 || table.Expires != 1782604800 {
+		t.Errorf("got updated %d expires %d, want 1751846400 1782604800", table.Updated, table.Expires)
+	}
+	if _, err := ParseLeapseconds(strings.NewReader("Leap 1972 Jun 30 23:59:60 * S\n")); err == nil {
+		t.Errorf("got nil, want an error for a bad correction")
+	}
+}
+
+// rightTZif returns version 4 TZif data in UTC with the given leap second records,
+// occurrence and correction pairs, in its 64-bit block.
+func rightTZif(leaps ...int64) []byte {
+	header := func(leapcnt int) []byte {
+		h := append([]byte("TZif4"), make([]byte, 15)...)
+		for _, count := range []int{0, 0, leapcnt, 0, 1, 4} {
+			h = binary.BigEndian.AppendUint32(h, uint32(count))
+		}
+		return append(h, 0, 0, 0, 0, 0, 0, 'U', 'T', 'C', 0)
+	}
+	data := append(header(0), header(len(leaps)/2)...)
+	for i := 0; i < len(leaps); i += 2 {
+		data = binary.BigEndian.AppendUint64(data, uint64(leaps[i]))
+		data = binary.BigEndian.AppendUint32(data, uint32(leaps[i+1]))
+	}
+	return append(data, "\nUTC0\n"...)
+}
+
+func TestCheckRecords(t *testing.T) {
+	table := &Table{Leaps: want, Expires: 1782604800}
+	for _, c := range []struct {
+		name  string
+		leaps []int64
+		ok    bool
+	}{
+		{"plain", []int64{78796800, 1, 94694401, 2}, true},
+		{"expiry", []int64{78796800, 1, 94694401, 2, 1782604802, 2}, true},
+		{"extra", []int64{78796800, 1, 94694401, 2, 1782604802, 3}, false},
+		{"missing", []int64{78796800, 1}, false},
+		{"moved", []int64{78796800, 1, 94694400, 2}, false},
+	} {
+		layout := rfc9636.Inspect(rightTZif(c.leaps...))
+		if len(layout.Problems) > 0 {
+			t.Fatalf("%s: %v", c.name, layout.Problems)
+		}
+		var records []Record
+		for _, lr := range layout.Blocks[len(layout.Blocks)-1].Leaps {
+			records = append(records, Record{Occurrence: lr.Occurrence, Correction: int(lr.Correction)})
+		}
+		if err := table.CheckRecords(records); (err == nil) != c.ok {
+			t.Errorf("%s: got %v, want ok %v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestSystemTables(t *testing.T) {
+	list, err := ReadList("/usr/share/zoneinfo/leap-seconds.list")
+	if os.IsNotExist(err) {
+		t.Skip("no leap-seconds.list")
+	}
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	zic, err := ReadLeapseconds("/usr/share/zoneinfo/leapseconds")
+	if err != nil {
+		t.Skipf("no leapseconds file: %v", err)
+	}
+	if !slices.Equal(list.Leaps, zic.Leaps) || list.Expires != zic.Expires {
+		t.Errorf("leap-seconds.list has %v expiring %d, leapseconds %v expiring %d", list.Leaps, list.Expires, zic.Leaps, zic.Expires)
+	}
+}
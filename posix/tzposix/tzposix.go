@@ -138,6 +138,196 @@ func HumanReadableTZ(posixTZ string) (string, error) {
 	return fmt.Sprintf("%s\n%s%s", stdDesc, dstDesc, rulesDesc), nil
 }
 
+// RuleKind identifies which of the three POSIX date forms a Rule uses.
+type RuleKind int
+
+const (
+	RuleJulian       RuleKind = iota // Jn: day 1-365, February 29 is never counted
+	RuleDayOfYear                    // n: day 0-365, February 29 is counted in leap years
+	RuleMonthWeekDay                 // Mm.w.d: weekday d of week w (5 = last) of month m
+)
+
+func (k RuleKind) String() string {
+	switch k {
+	case RuleJulian:
+		return "Julian"
+	case RuleDayOfYear:
+		return "DayOfYear"
+	case RuleMonthWeekDay:
+		return "MonthWeekDay"
+	}
+	return "Unknown"
+}
+
+// Rule is a DST start or end rule from a POSIX TZ string.
+// Time is the local wall clock time of the change in seconds after midnight.
+// It defaults to 02:00:00 and may be negative or exceed 24 hours.
+type Rule struct {
+	Kind  RuleKind
+	Month int // 1-12, RuleMonthWeekDay only
+	Week  int // 1-5, RuleMonthWeekDay only
+	Day   int // weekday 0-6 for RuleMonthWeekDay, day number otherwise
+	Time  int
+}
+
+// TZ is the structured form of a POSIX TZ string.
+// Offsets are seconds east of UTC, the opposite sign of the string itself,
+// and names are stripped of any enclosing angle brackets.
+type TZ struct {
+	Raw       string // the TZ string as given
+	StdName   string
+	StdOffset int
+	DstName   string // empty when there is no daylight saving time
+	DstOffset int
+	Start     Rule
+	End       Rule
+}
+
+// HasDST reports whether the TZ string describes a daylight saving time.
+func (tz TZ) HasDST() bool {
+	return tz.DstName != ""
+}
+
+// Saving returns the number of seconds added to standard time during daylight saving time.
+// It is negative for zones such as Europe/Dublin whose "standard" time is the summer time.
+func (tz TZ) Saving() int {
+	if !tz.HasDST() {
+		return 0
+	}
+	return tz.DstOffset - tz.StdOffset
+}
+
+// Parse decodes a POSIX TZ string into its structured form.
+// When a DST name is present without rules the POSIX default rules
+// M3.2.0,M11.1.0 are used, as tzcode does.
+func Parse(posixTZ string) (TZ, error) {
+	re := regexp.MustCompile(getTZRegex())
+
+	matches := re.FindStringSubmatch(posixTZ)
+	if matches == nil {
+		return TZ{}, fmt.Errorf("invalid POSIX TZ string format: %s", posixTZ)
+	}
+
+	tz := TZ{Raw: posixTZ, StdName: strings.Trim(matches[1], "<>")}
+	stdOffset, err := parseOffset(matches[2])
+	if err != nil {
+		return TZ{}, fmt.Errorf("invalid standard offset: %w", err)
+	}
+	tz.StdOffset = -stdOffset
+
+	if matches[3] == "" {
+		if matches[5] != "" || matches[6] != "" {
+			return TZ{}, fmt.Errorf("rules without a daylight time name: %s", posixTZ)
+		}
+		return tz, nil
+	}
+	tz.DstName = strings.Trim(matches[3], "<>")
+	tz.DstOffset = tz.StdOffset + 3600
+	if matches[4] != "" {
+		dstOffset, err := parseOffset(matches[4])
+		if err != nil {
+			return TZ{}, fmt.Errorf("invalid daylight offset: %w", err)
+		}
+		tz.DstOffset = -dstOffset
+	}
+
+	startRule, endRule := matches[5], matches[6]
+	if startRule == "" && endRule == "" {
+		startRule, endRule = "M3.2.0", "M11.1.0"
+	} else if startRule == "" || endRule == "" {
+		return TZ{}, fmt.Errorf("daylight time needs both a start and an end rule: %s", posixTZ)
+	}
+	if tz.Start, err = ParseRule(startRule); err != nil {
+		return TZ{}, err
+	}
+	if tz.End, err = ParseRule(endRule); err != nil {
+		return TZ{}, err
+	}
+	return tz, nil
+}
+
+// Transitions returns the instants, in seconds since 1970 UTC, at which daylight
+// saving time starts and ends in year. ok is false when there is no daylight
+// saving time. In the southern hemisphere end is before start.
+func (tz TZ) Transitions(year int) (start, end int64, ok bool) {
+	if !tz.HasDST() {
+		return 0, 0, false
+	}
+	// The start rule is given in standard time and the end rule in daylight time.
+	start = tz.Start.Date(year).Unix() + int64(tz.Start.Time-tz.StdOffset)
+	end = tz.End.Date(year).Unix() + int64(tz.End.Time-tz.DstOffset)
+	return start, end, true
+}
+
+// Date returns midnight UTC of the day in year selected by the rule.
+func (r Rule) Date(year int) time.Time {
+	switch r.Kind {
+	case RuleJulian:
+		day := r.Day
+		if day >= 60 && time.Date(year, time.February, 29, 0, 0, 0, 0, time.UTC).Month() == time.February {
+			day++ // Jn never counts February 29
+		}
+		return time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC)
+	case RuleDayOfYear:
+		return time.Date(year, time.January, r.Day+1, 0, 0, 0, 0, time.UTC)
+	}
+	first := time.Date(year, time.Month(r.Month), 1, 0, 0, 0, 0, time.UTC)
+	day := 1 + (r.Day-int(first.Weekday())+7)%7 + (r.Week-1)*7
+	for day > first.AddDate(0, 1, -1).Day() {
+		day -= 7 // week 5 means the last one
+	}
+	return time.Date(year, time.Month(r.Month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseRule decodes a single POSIX date rule such as "M3.2.0/2", "J60" or "59/-1".
+func ParseRule(rule string) (Rule, error) {
+	r := Rule{Time: 2 * 3600}
+	date, timeStr, hasTime := strings.Cut(rule, "/")
+	if hasTime {
+		t, err := parseOffset(timeStr)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid rule time %q: %w", rule, err)
+		}
+		r.Time = t
+	}
+
+	var err error
+	switch {
+	case strings.HasPrefix(date, "M"):
+		parts := strings.Split(date[1:], ".")
+		if len(parts) != 3 {
+			return Rule{}, fmt.Errorf("invalid month rule %q", rule)
+		}
+		r.Kind = RuleMonthWeekDay
+		if r.Month, err = ruleNumber(parts[0], 1, 12); err == nil {
+			if r.Week, err = ruleNumber(parts[1], 1, 5); err == nil {
+				r.Day, err = ruleNumber(parts[2], 0, 6)
+			}
+		}
+	case strings.HasPrefix(date, "J"):
+		r.Kind = RuleJulian
+		r.Day, err = ruleNumber(date[1:], 1, 365)
+	default:
+		r.Kind = RuleDayOfYear
+		r.Day, err = ruleNumber(date, 0, 365)
+	}
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", rule, err)
+	}
+	return r, nil
+}
+
+func ruleNumber(s string, min, max int) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%d is outside %d-%d", n, min, max)
+	}
+	return n, nil
+}
+
 // parseOffset converts a POSIX offset string (e.g., "5", "-10:30") to seconds west of UTC
 func parseOffset(offsetStr string) (int, error) {
 	// POSIX offsets are West of Greenwich, opposite of ISO 8601
@@ -4,6 +4,7 @@ import (
 	_ "fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHumanReadableTZAll(t *testing.T) {
@@ -270,3 +271,98 @@ func TestHumanReadableTZNoDst(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		tz     string
+		expect TZ
+	}{
+		{tz: "EST5EDT,M3.2.0,M11.1.0",
+			expect: TZ{StdName: "EST", StdOffset: -18000, DstName: "EDT", DstOffset: -14400,
+				Start: Rule{Kind: RuleMonthWeekDay, Month: 3, Week: 2, Day: 0, Time: 7200},
+				End:   Rule{Kind: RuleMonthWeekDay, Month: 11, Week: 1, Day: 0, Time: 7200}},
+		},
+		{tz: "<+0330>-3:30",
+			expect: TZ{StdName: "+0330", StdOffset: 12600},
+		},
+		{tz: "IST-1GMT0,M10.5.0,M3.5.0/1",
+			expect: TZ{StdName: "IST", StdOffset: 3600, DstName: "GMT", DstOffset: 0,
+				Start: Rule{Kind: RuleMonthWeekDay, Month: 10, Week: 5, Day: 0, Time: 7200},
+				End:   Rule{Kind: RuleMonthWeekDay, Month: 3, Week: 5, Day: 0, Time: 3600}},
+		},
+		{tz: "<-02>2<-01>,M3.5.0/-1,M10.5.0/0",
+			expect: TZ{StdName: "-02", StdOffset: -7200, DstName: "-01", DstOffset: -3600,
+				Start: Rule{Kind: RuleMonthWeekDay, Month: 3, Week: 5, Day: 0, Time: -3600},
+				End:   Rule{Kind: RuleMonthWeekDay, Month: 10, Week: 5, Day: 0, Time: 0}},
+		},
+		{tz: "<+00>0<+01>,0/0,J365/25",
+			expect: TZ{StdName: "+00", StdOffset: 0, DstName: "+01", DstOffset: 3600,
+				Start: Rule{Kind: RuleDayOfYear, Day: 0, Time: 0},
+				End:   Rule{Kind: RuleJulian, Day: 365, Time: 25 * 3600}},
+		},
+		{tz: "EET-2EEST,M3.4.4/50,M10.4.4/50",
+			expect: TZ{StdName: "EET", StdOffset: 7200, DstName: "EEST", DstOffset: 10800,
+				Start: Rule{Kind: RuleMonthWeekDay, Month: 3, Week: 4, Day: 4, Time: 50 * 3600},
+				End:   Rule{Kind: RuleMonthWeekDay, Month: 10, Week: 4, Day: 4, Time: 50 * 3600}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			tt.expect.Raw = tt.tz
+			got, err := Parse(tt.tz)
+			if err != nil {
+				t.Errorf("got %v, want nil", err)
+				return
+			}
+			if got != tt.expect {
+				t.Errorf("got %+v\nwant %+v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tz := range []string{"", "EST", "EST5EDT,M3.2.0", "EST5EDT,M13.2.0,M11.1.0", "EST5EDT,J0,J365"} {
+		t.Run(tz, func(t *testing.T) {
+			if got, err := Parse(tz); err == nil {
+				t.Errorf("got %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	var tests = []struct {
+		tz          string
+		year        int
+		expectStart string
+		expectEnd   string
+	}{
+		{tz: "EST5EDT,M3.2.0,M11.1.0", year: 2027, expectStart: "2027-03-14T07:00:00Z", expectEnd: "2027-11-07T06:00:00Z"},
+		{tz: "AEST-10AEDT,M10.1.0,M4.1.0/3", year: 2027, expectStart: "2027-10-02T16:00:00Z", expectEnd: "2027-04-03T16:00:00Z"},
+		{tz: "IST-1GMT0,M10.5.0,M3.5.0/1", year: 2027, expectStart: "2027-10-31T01:00:00Z", expectEnd: "2027-03-28T01:00:00Z"},
+		{tz: "<-04>4<-03>,M9.1.6/24,M4.1.6/24", year: 2027, expectStart: "2027-09-05T04:00:00Z", expectEnd: "2027-04-04T03:00:00Z"},
+		{tz: "EET-2EEST,M3.4.4/50,M10.4.4/50", year: 2028, expectStart: "2028-03-25T00:00:00Z", expectEnd: "2028-10-27T23:00:00Z"},
+		{tz: "<+00>0<+01>,0/0,J365/25", year: 2028, expectStart: "2028-01-01T00:00:00Z", expectEnd: "2029-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			tz, err := Parse(tt.tz)
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+			start, end, ok := tz.Transitions(tt.year)
+			if !ok {
+				t.Fatalf("got no transitions")
+			}
+			if got := time.Unix(start, 0).UTC().Format(time.RFC3339); got != tt.expectStart {
+				t.Errorf("start got %s want %s", got, tt.expectStart)
+			}
+			if got := time.Unix(end, 0).UTC().Format(time.RFC3339); got != tt.expectEnd {
+				t.Errorf("end got %s want %s", got, tt.expectEnd)
+			}
+		})
+	}
+}
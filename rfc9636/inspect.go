@@ -0,0 +1,277 @@
+package rfc9636
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// A Layout is the byte level structure of TZif data, decoded as far as the data allows.
+// Unlike LoadLocationFromTZData, Inspect does not give up on malformed data:
+// it records what is wrong in Problems and stops only where the structure is lost.
+type Layout struct {
+	Size     int
+	Blocks   []Block // the version 1 data block and, from version 2, the 64-bit one
+	Footer   *Footer // the POSIX TZ string of version 2 and later
+	Trailing *Span   // bytes after the end of the TZif data
+	Problems []string
+}
+
+// A Span is a named range of bytes of the data.
+type Span struct {
+	Name   string
+	Offset int
+	Length int
+}
+
+// End returns the offset of the first byte after s.
+func (s Span) End() int {
+	return s.Offset + s.Length
+}
+
+// A Header is the 44 byte header in front of each data block.
+type Header struct {
+	Span
+	Magic    string
+	Version  byte
+	Isutcnt  int
+	Isstdcnt int
+	Leapcnt  int
+	Timecnt  int
+	Typecnt  int
+	Charcnt  int
+}
+
+// A Block is a header and the data block it describes.
+type Block struct {
+	Header   Header
+	TimeSize int    // 4 in the version 1 block, 8 in the 64-bit one
+	Sections []Span // the sections of the data block in file order
+	Types    []TypeRecord
+	Abbrevs  []Abbrev
+	Leaps    []LeapRecord
+}
+
+// A TypeRecord is a ttinfo record of a data block: a local time type.
+type TypeRecord struct {
+	Offset   int
+	Raw      string // the 6 bytes in hex
+	UTOff    int32
+	IsDST    byte
+	DesigIdx byte
+	Abbr     string
+}
+
+// An Abbrev is one of the NUL terminated strings of the time zone designation table.
+type Abbrev struct {
+	Index  int // position in the table, the desigidx of the types using it
+	Offset int
+	Text   string
+}
+
+// A LeapRecord is a leap second record of a data block.
+type LeapRecord struct {
+	Offset     int
+	Occurrence int64
+	Correction int32
+}
+
+// Footer is the newline enclosed POSIX TZ string at the end of version 2 and later data.
+type Footer struct {
+	Span
+	Text string
+}
+
+// headerSize is the length of a TZif header: magic, version, 15 unused bytes and six counts.
+const headerSize = 44
+
+// Inspect decodes the structure of TZif data.
+func Inspect(data []byte) *Layout {
+	l := &Layout{Size: len(data)}
+	v1, ok := l.block(data, 0, 4)
+	if !ok {
+		return l
+	}
+	end := v1.Header.Offset + v1.Header.Length
+	for _, s := range v1.Sections {
+		end = s.End()
+	}
+	version := v1.Header.Version
+	if version != 0 {
+		v2, ok := l.block(data, end, 8)
+		if !ok {
+			return l
+		}
+		if v2.Header.Version != version {
+			l.problem("the version of the second header is %q, not %q", v2.Header.Version, version)
+		}
+		end = v2.Header.End()
+		for _, s := range v2.Sections {
+			end = s.End()
+		}
+		l.footer(data, end)
+		if l.Footer != nil {
+			end = l.Footer.End()
+		}
+	}
+	if end < len(data) {
+		l.Trailing = &Span{Name: "trailing data", Offset: end, Length: len(data) - end}
+		l.problem("%d bytes of trailing data at 0x%04x", len(data)-end, end)
+	}
+	return l
+}
+
+func (l *Layout) problem(format string, args ...any) {
+	l.Problems = append(l.Problems, fmt.Sprintf(format, args...))
+}
+
+// block decodes the header at offset and the data block following it,
+// or as much of them as there is data for.
+func (l *Layout) block(data []byte, offset, timeSize int) (Block, bool) {
+	b := Block{TimeSize: timeSize}
+	name := "v1"
+	if timeSize == 8 {
+		name = "v2+"
+	}
+	if len(data)-offset < headerSize {
+		l.problem("the %s header at 0x%04x needs %d bytes, %d left", name, offset, headerSize, len(data)-offset)
+		return b, false
+	}
+	h := data[offset : offset+headerSize]
+	b.Header = Header{Span: Span{Name: name + " header", Offset: offset, Length: headerSize}, Magic: string(h[:4]), Version: h[4]}
+	counts := make([]int, 6)
+	for i := range counts {
+		counts[i] = int(binary.BigEndian.Uint32(h[20+4*i:]))
+	}
+	b.Header.Isutcnt, b.Header.Isstdcnt, b.Header.Leapcnt = counts[0], counts[1], counts[2]
+	b.Header.Timecnt, b.Header.Typecnt, b.Header.Charcnt = counts[3], counts[4], counts[5]
+	l.Blocks = append(l.Blocks, b)
+	block := &l.Blocks[len(l.Blocks)-1]
+
+	if b.Header.Magic != "TZif" {
+		l.problem("the %s header at 0x%04x starts with %q, not \"TZif\"", name, offset, b.Header.Magic)
+		return b, false
+	}
+	switch b.Header.Version {
+	case 0, '2', '3', '4':
+	default:
+		l.problem("unknown version %q", b.Header.Version)
+	}
+	if b.Header.Typecnt == 0 {
+		l.problem("the %s header has no local time types", name)
+	}
+	if b.Header.Charcnt == 0 {
+		l.problem("the %s header has no time zone designations", name)
+	}
+	if b.Header.Isutcnt != 0 && b.Header.Isutcnt != b.Header.Typecnt {
+		l.problem("the %s header has isutcnt %d, not 0 or typecnt", name, b.Header.Isutcnt)
+	}
+	if b.Header.Isstdcnt != 0 && b.Header.Isstdcnt != b.Header.Typecnt {
+		l.problem("the %s header has isstdcnt %d, not 0 or typecnt", name, b.Header.Isstdcnt)
+	}
+
+	pos := offset + headerSize
+	sections := []Span{
+		{"transition times", 0, b.Header.Timecnt * timeSize},
+		{"transition types", 0, b.Header.Timecnt},
+		{"local time types", 0, b.Header.Typecnt * 6},
+		{"designations", 0, b.Header.Charcnt},
+		{"leap seconds", 0, b.Header.Leapcnt * (timeSize + 4)},
+		{"standard/wall indicators", 0, b.Header.Isstdcnt},
+		{"UT/local indicators", 0, b.Header.Isutcnt},
+	}
+	for i := range sections {
+		sections[i].Name = name + " " + sections[i].Name
+		sections[i].Offset = pos
+		if pos+sections[i].Length > len(data) {
+			l.problem("the %s at 0x%04x need %d bytes, %d left", sections[i].Name, pos, sections[i].Length, len(data)-pos)
+			block.Sections = sections[:i]
+			return *block, false
+		}
+		pos += sections[i].Length
+	}
+	block.Sections = sections
+	section := func(i int) []byte {
+		return data[sections[i].Offset:sections[i].End()]
+	}
+
+	times, indices := section(0), section(1)
+	prev := int64(-1 << 63)
+	for i := range b.Header.Timecnt {
+		when := int64(int32(binary.BigEndian.Uint32(times[i*timeSize:])))
+		if timeSize == 8 {
+			when = int64(binary.BigEndian.Uint64(times[i*timeSize:]))
+		}
+		if i > 0 && when <= prev {
+			l.problem("the %s transition %d at 0x%04x is not after the one before it", name, i, sections[0].Offset+i*timeSize)
+			break
+		}
+		prev = when
+	}
+	for i, index := range indices {
+		if int(index) >= b.Header.Typecnt {
+			l.problem("the %s transition %d has type %d of %d", name, i, index, b.Header.Typecnt)
+			break
+		}
+	}
+
+	abbrevs := section(3)
+	for start := 0; start < len(abbrevs); {
+		n := bytes.IndexByte(abbrevs[start:], 0)
+		if n < 0 {
+			l.problem("the %s designations do not end with NUL", name)
+			n = len(abbrevs) - start
+		}
+		block.Abbrevs = append(block.Abbrevs, Abbrev{Index: start, Offset: sections[3].Offset + start, Text: string(abbrevs[start : start+n])})
+		start += n + 1
+	}
+
+	types := section(2)
+	for i := range b.Header.Typecnt {
+		raw := types[i*6 : i*6+6]
+		tt := TypeRecord{Offset: sections[2].Offset + i*6, Raw: hex.EncodeToString(raw),
+			UTOff: int32(binary.BigEndian.Uint32(raw)), IsDST: raw[4], DesigIdx: raw[5]}
+		if int(tt.DesigIdx) < len(abbrevs) {
+			tt.Abbr = byteString(abbrevs[tt.DesigIdx:])
+		} else {
+			l.problem("the %s local time type %d has desigidx %d of %d", name, i, tt.DesigIdx, len(abbrevs))
+		}
+		if tt.IsDST > 1 {
+			l.problem("the %s local time type %d has isdst %d", name, i, tt.IsDST)
+		}
+		if tt.UTOff == -1<<31 {
+			l.problem("the %s local time type %d has utoff -2**31", name, i)
+		}
+		block.Types = append(block.Types, tt)
+	}
+
+	leaps := section(4)
+	for i := range b.Header.Leapcnt {
+		rec := leaps[i*(timeSize+4):]
+		lr := LeapRecord{Offset: sections[4].Offset + i*(timeSize+4)}
+		if timeSize == 8 {
+			lr.Occurrence = int64(binary.BigEndian.Uint64(rec))
+		} else {
+			lr.Occurrence = int64(int32(binary.BigEndian.Uint32(rec)))
+		}
+		lr.Correction = int32(binary.BigEndian.Uint32(rec[timeSize:]))
+		block.Leaps = append(block.Leaps, lr)
+	}
+	return *block, true
+}
+
+// footer decodes the footer at offset.
+func (l *Layout) footer(data []byte, offset int) {
+	rest := data[offset:]
+	if len(rest) == 0 || rest[0] != '\n' {
+		l.problem("no footer at 0x%04x", offset)
+		return
+	}
+	n := bytes.IndexByte(rest[1:], '\n')
+	if n < 0 {
+		l.problem("the footer at 0x%04x does not end with a newline", offset)
+		return
+	}
+	l.Footer = &Footer{Span: Span{Name: "footer", Offset: offset, Length: n + 2}, Text: string(rest[1 : n+1])}
+}
@@ -0,0 +1,140 @@
+package rfc9636
+
+import (
+	"errors"
+	"slices"
+)
+
+// A WallClockKind tells how a local wall clock time maps to instants.
+type WallClockKind int
+
+const (
+	WallClockUnique  WallClockKind = iota // the wall clock time occurs once
+	WallClockGap                          // the wall clock time is skipped by a transition
+	WallClockOverlap                      // the wall clock time is repeated by a transition
+)
+
+func (k WallClockKind) String() string {
+	switch k {
+	case WallClockUnique:
+		return "unique"
+	case WallClockGap:
+		return "gap"
+	case WallClockOverlap:
+		return "overlap"
+	}
+	return "unknown"
+}
+
+// A WallClock is the resolution of a local wall clock time in a Location.
+// Local is the wall clock time in seconds since 1970 as if it were UTC.
+// Instants holds zero instants for a gap, one normally and two, in increasing
+// order, for an overlap. Transition is the transition causing a gap or overlap.
+type WallClock struct {
+	Local      int64
+	Kind       WallClockKind
+	Instants   []int64
+	Transition Transition
+}
+
+// A Policy selects a single instant for a wall clock time that is skipped or repeated.
+type Policy int
+
+const (
+	// PolicyEarlier uses the earlier instant of an overlap. In a gap it reads the
+	// time with the offset after the transition, which moves it back by the gap.
+	PolicyEarlier Policy = iota
+	// PolicyLater uses the later instant of an overlap. In a gap it reads the
+	// time with the offset before the transition, which moves it forward by the
+	// gap as mktime does.
+	PolicyLater
+	// PolicyShiftForward uses the earlier instant of an overlap and the instant of
+	// the transition, the first valid wall clock time after a gap.
+	PolicyShiftForward
+	// PolicyReject fails with ErrWallClockGap or ErrWallClockOverlap.
+	PolicyReject
+)
+
+var (
+	ErrWallClockGap     = errors.New("local time is skipped by a time zone transition")
+	ErrWallClockOverlap = errors.New("local time is repeated by a time zone transition")
+)
+
+// wallClockWindow bounds the distance between a wall clock time and its instants,
+// which is at most the largest UTC offset in use plus any DST saving.
+const wallClockWindow = 2 * secondsPerDay
+
+// WallClock maps a local wall clock time, given in seconds since 1970 as if it were UTC,
+// to the instants at which the Location's clocks show it. Both the transition table and
+// the extend string are consulted.
+func (l *Location) WallClock(local int64) WallClock {
+	first, _, _ := l.Lookup(local - wallClockWindow)
+	txs := l.Transitions(local-wallClockWindow, local+wallClockWindow)
+
+	offsets := []int{first.Offset}
+	for _, tx := range txs {
+		if !slices.Contains(offsets, tx.After.Offset) {
+			offsets = append(offsets, tx.After.Offset)
+		}
+	}
+	wc := WallClock{Local: local}
+	for _, offset := range offsets {
+		sec := local - int64(offset)
+		if tt, _, _ := l.Lookup(sec); tt.Offset == offset && !slices.Contains(wc.Instants, sec) {
+			wc.Instants = append(wc.Instants, sec)
+		}
+	}
+	slices.Sort(wc.Instants)
+
+	switch len(wc.Instants) {
+	case 1:
+		return wc
+	case 0:
+		wc.Kind = WallClockGap
+	default:
+		wc.Kind = WallClockOverlap
+		// Only adjacent transitions can repeat a time; keep the first and last.
+		wc.Instants = []int64{wc.Instants[0], wc.Instants[len(wc.Instants)-1]}
+	}
+	for _, tx := range txs {
+		before := tx.When + int64(tx.Before.Offset)
+		after := tx.When + int64(tx.After.Offset)
+		if (wc.Kind == WallClockGap && before <= local && local < after) ||
+			(wc.Kind == WallClockOverlap && after <= local && local < before) {
+			wc.Transition = tx
+			break
+		}
+	}
+	return wc
+}
+
+// Resolve returns the single instant for a local wall clock time, choosing between
+// the instants of an overlap or past a gap according to policy. The WallClock
+// reports which case applied.
+func (l *Location) Resolve(local int64, policy Policy) (int64, WallClock, error) {
+	wc := l.WallClock(local)
+	switch wc.Kind {
+	case WallClockUnique:
+		return wc.Instants[0], wc, nil
+	case WallClockOverlap:
+		switch policy {
+		case PolicyReject:
+			return 0, wc, ErrWallClockOverlap
+		case PolicyLater:
+			return wc.Instants[1], wc, nil
+		default:
+			return wc.Instants[0], wc, nil
+		}
+	default:
+		switch policy {
+		case PolicyReject:
+			return 0, wc, ErrWallClockGap
+		case PolicyEarlier:
+			return local - int64(wc.Transition.After.Offset), wc, nil
+		case PolicyLater:
+			return local - int64(wc.Transition.Before.Offset), wc, nil
+		default:
+			return wc.Transition.When, wc, nil
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package rfc9636
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	wall := func(year int, month time.Month, day, hour, min int) int64 {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC).Unix()
+	}
+	utc := wall
+
+	var tests = []struct {
+		zone   string
+		local  int64
+		policy Policy
+		kind   WallClockKind
+		expect int64
+		err    error
+	}{
+		{"America/New_York", wall(2026, 7, 1, 12, 0), PolicyReject, WallClockUnique, utc(2026, 7, 1, 16, 0), nil},
+		{"America/New_York", wall(2026, 3, 8, 2, 30), PolicyEarlier, WallClockGap, utc(2026, 3, 8, 6, 30), nil},
+		{"America/New_York", wall(2026, 3, 8, 2, 30), PolicyLater, WallClockGap, utc(2026, 3, 8, 7, 30), nil},
+		{"America/New_York", wall(2026, 3, 8, 2, 30), PolicyShiftForward, WallClockGap, utc(2026, 3, 8, 7, 0), nil},
+		{"America/New_York", wall(2026, 3, 8, 2, 30), PolicyReject, WallClockGap, 0, ErrWallClockGap},
+		{"America/New_York", wall(2026, 11, 1, 1, 30), PolicyEarlier, WallClockOverlap, utc(2026, 11, 1, 5, 30), nil},
+		{"America/New_York", wall(2026, 11, 1, 1, 30), PolicyLater, WallClockOverlap, utc(2026, 11, 1, 6, 30), nil},
+		{"America/New_York", wall(2026, 11, 1, 1, 30), PolicyReject, WallClockOverlap, 0, ErrWallClockOverlap},
+		// Footer driven, southern hemisphere.
+		{"Australia/Sydney", wall(2045, 10, 1, 2, 0), PolicyShiftForward, WallClockGap, utc(2045, 9, 30, 16, 0), nil},
+		{"Australia/Sydney", wall(2045, 4, 2, 2, 59), PolicyLater, WallClockOverlap, utc(2045, 4, 1, 16, 59), nil},
+		// Negative DST: the winter change is the backward one.
+		{"Europe/Dublin", wall(2026, 10, 25, 1, 15), PolicyEarlier, WallClockOverlap, utc(2026, 10, 25, 0, 15), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.zone+" "+time.Unix(tt.local, 0).UTC().Format("2006-01-02T15:04"), func(t *testing.T) {
+			l := loadTestLocation(t, tt.zone)
+			got, wc, err := l.Resolve(tt.local, tt.policy)
+			if wc.Kind != tt.kind {
+				t.Errorf("got kind %v, want %v", wc.Kind, tt.kind)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if got != tt.expect {
+				t.Errorf("got %s, want %s", time.Unix(got, 0).UTC(), time.Unix(tt.expect, 0).UTC())
+			}
+		})
+	}
+}
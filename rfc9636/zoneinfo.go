@@ -7,7 +7,9 @@ package rfc9636
 
 import (
 	"fmt"
+	"io"
 	"sync"
+	"time"
 )
 
 //go:generate env ZONEINFO=$GOROOT/lib/time/zoneinfo.zip go run genzabbrs.go -output zoneinfo_abbrs_windows.go
@@ -32,6 +34,10 @@ type Location struct {
 	// Example string, for America/Los_Angeles: PST8PDT,M3.2.0,M11.1.0
 	extend string
 
+	// leaps are the leap second records of data such as the right/ zones, whose
+	// times count the inserted leap seconds. Lookups ignore them.
+	leaps []leapSecond
+
 	// Most lookups will be for the current time.
 	// To avoid the binary search through tx, keep a
 	// static one-element cache that gives the correct
@@ -60,6 +66,14 @@ type zoneTrans struct {
 	isstd, isutc bool  // ignored - no idea what these mean
 }
 
+// A leapSecond is a leap second record: from when on, correction seconds have been
+// inserted in total. The last record repeats the correction of the one before it when
+// it only marks when the table expires.
+type leapSecond struct {
+	when       int64
+	correction int64
+}
+
 // alpha and omega are the beginning and end of time for zone
 // transitions.
 const (
@@ -89,18 +103,640 @@ var localLoc Location
 var localOnce sync.Once
 
 func (tzInfo *Location) Extend() string {
-	return tzInfo.extend
+	return tzInfo.get().extend
+}
+
+// DumpLocation writes the transitions of l in [from, to) to w the way zdump -v does:
+// two lines per transition, for the second before it and the second it takes effect,
+// each giving the UT and local time, the abbreviation, isdst and the offset.
+// Transitions past the table end come from the footer.
+func DumpLocation(w io.Writer, l *Location, from, to int64) error {
+	for _, tx := range l.Transitions(from, to) {
+		if _, err := fmt.Fprintln(w, DumpLine(l.name, tx.When-1, tx.Before)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, DumpLine(l.name, tx.When, tx.After)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpLine formats the local time type tt in force at sec as a zdump -v line.
+func DumpLine(name string, sec int64, tt TimeType) string {
+	const layout = "Mon Jan _2 15:04:05 2006"
+	t := time.Unix(sec, 0)
+	isDST := 0
+	if tt.IsDST {
+		isDST = 1
+	}
+	return fmt.Sprintf("%s  %s UT = %s %s isdst=%d gmtoff=%d", name, t.UTC().Format(layout),
+		t.In(time.FixedZone(tt.Name, tt.Offset)).Format(layout), tt.Name, isDST, tt.Offset)
+}
+
+const (
+	secondsPerMinute = 60
+	secondsPerHour   = 60 * secondsPerMinute
+	secondsPerDay    = 24 * secondsPerHour
+)
+
+// A TimeType is a local time type as defined by RFC 9636:
+// the abbreviation, UTC offset and DST flag in effect for a span of time.
+type TimeType struct {
+	Name   string // abbreviated name, "CET"
+	Offset int    // seconds east of UTC
+	IsDST  bool   // is this zone Daylight Savings Time?
+}
+
+// A Transition is an instant at which a Location changes from one
+// local time type to another.
+type Transition struct {
+	When   int64 // transition time, in seconds since 1970 GMT
+	Before TimeType
+	After  TimeType
+}
+
+// Name returns the name the Location was loaded with.
+func (l *Location) Name() string {
+	return l.get().name
+}
+
+// TableEnd returns the time of the last transition recorded in the TZif data.
+// After it the extend string, if any, describes the zone. ok is false when the
+// data has no transitions, as for fixed zones like "Etc/GMT0".
+func (l *Location) TableEnd() (sec int64, ok bool) {
+	l = l.get()
+	if len(l.tx) == 0 || l.tx[len(l.tx)-1].when == alpha {
+		return 0, false
+	}
+	return l.tx[len(l.tx)-1].when, true
+}
+
+// Lookup returns the local time type in use at an instant in time
+// expressed as seconds since January 1, 1970 00:00:00 UTC, together with
+// the start and end times bracketing sec when that type is in effect.
+// Past the last transition of the table the extend string is consulted.
+func (l *Location) Lookup(sec int64) (tt TimeType, start, end int64) {
+	name, offset, start, end, isDST := l.lookup(sec)
+	return TimeType{name, offset, isDST}, start, end
+}
+
+// Transitions returns the transitions with from <= When < to, taken from the
+// transition table and, past the end of the table, from the extend string.
+// Table entries that do not change the local time type are skipped.
+func (l *Location) Transitions(from, to int64) []Transition {
+	var txs []Transition
+	l.transitions(from, func(tx Transition) bool {
+		if tx.When >= to {
+			return false
+		}
+		txs = append(txs, tx)
+		return true
+	})
+	return txs
+}
+
+// NextTransitions returns at most n transitions at or after from.
+func (l *Location) NextTransitions(from int64, n int) []Transition {
+	txs := make([]Transition, 0, n)
+	if n <= 0 {
+		return txs
+	}
+	l.transitions(from, func(tx Transition) bool {
+		txs = append(txs, tx)
+		return len(txs) < n
+	})
+	return txs
+}
+
+// transitions calls yield for each transition at or after from
+// until yield returns false or time runs out.
+func (l *Location) transitions(from int64, yield func(Transition) bool) {
+	before, start, end := l.Lookup(from)
+	if start == from && from != alpha {
+		// A transition exactly at from is in force at from; report it as well.
+		if prev, _, _ := l.Lookup(from - 1); prev != before {
+			if !yield(Transition{When: from, Before: prev, After: before}) {
+				return
+			}
+		}
+	}
+	// The extend string splits a year in at most three spans, so once past the table
+	// four spans without a change mean that no change is coming, as with permanent DST.
+	tableEnd, hasTable := l.TableEnd()
+	unchanged := 0
+	for end != omega {
+		after, _, next := l.Lookup(end)
+		if after != before {
+			unchanged = 0
+			if !yield(Transition{When: end, Before: before, After: after}) {
+				return
+			}
+		} else if unchanged++; unchanged > 3 && (!hasTable || end > tableEnd) {
+			return
+		}
+		if next <= end {
+			return
+		}
+		before, end = after, next
+	}
+}
+
+// lookup returns information about the time zone in use at an
+// instant in time expressed as seconds since January 1, 1970 00:00:00 UTC.
+//
+// The returned information gives the name of the zone (such as "CET"),
+// the start and end times bracketing sec when that zone is in effect,
+// the offset in seconds east of UTC (such as -5*60*60), and whether
+// the daylight savings is being observed at that time.
+func (l *Location) lookup(sec int64) (name string, offset int, start, end int64, isDST bool) {
+	l = l.get()
+
+	if len(l.zone) == 0 {
+		name = "UTC"
+		offset = 0
+		start = alpha
+		end = omega
+		isDST = false
+		return
+	}
+
+	if zone := l.cacheZone; zone != nil && l.cacheStart <= sec && sec < l.cacheEnd {
+		name = zone.name
+		offset = zone.offset
+		start = l.cacheStart
+		end = l.cacheEnd
+		isDST = zone.isDST
+		return
+	}
+
+	if len(l.tx) == 0 || sec < l.tx[0].when {
+		zone := &l.zone[l.lookupFirstZone()]
+		name = zone.name
+		offset = zone.offset
+		start = alpha
+		if len(l.tx) > 0 {
+			end = l.tx[0].when
+		} else {
+			end = omega
+		}
+		isDST = zone.isDST
+		return
+	}
+
+	// Binary search for entry with largest time <= sec.
+	// Not using sort.Search to avoid dependencies.
+	tx := l.tx
+	end = omega
+	lo := 0
+	hi := len(tx)
+	for hi-lo > 1 {
+		m := int(uint(lo+hi) >> 1)
+		lim := tx[m].when
+		if sec < lim {
+			end = lim
+			hi = m
+		} else {
+			lo = m
+		}
+	}
+	zone := &l.zone[tx[lo].index]
+	name = zone.name
+	offset = zone.offset
+	start = tx[lo].when
+	// end = maintained during the search
+	isDST = zone.isDST
+
+	// If we're at the end of the known zone transitions,
+	// try the extend string.
+	if lo == len(tx)-1 && l.extend != "" {
+		if ename, eoffset, estart, eend, eisDST, ok := tzset(l.extend, start, sec); ok {
+			return ename, eoffset, estart, eend, eisDST
+		}
+	}
+
+	return
+}
+
+// lookupFirstZone returns the index of the time zone to use for times
+// before the first transition time, or when there are no transition
+// times.
+//
+// The reference implementation in localtime.c from
+// https://www.iana.org/time-zones/repository/releases/tzcode2013g.tar.gz
+// implements the following algorithm for these cases:
+//  1. If the first zone is unused by the transitions, use it.
+//  2. Otherwise, if there are transition times, and the first
+//     transition is to a zone in daylight time, find the first
+//     non-daylight-time zone before and closest to the first transition
+//     zone.
+//  3. Otherwise, use the first zone that is not daylight time, if
+//     there is one.
+//  4. Otherwise, use the first zone.
+func (l *Location) lookupFirstZone() int {
+	// Case 1.
+	if !l.firstZoneUsed() {
+		return 0
+	}
+
+	// Case 2.
+	if len(l.tx) > 0 && l.zone[l.tx[0].index].isDST {
+		for zi := int(l.tx[0].index) - 1; zi >= 0; zi-- {
+			if !l.zone[zi].isDST {
+				return zi
+			}
+		}
+	}
+
+	// Case 3.
+	for zi := range l.zone {
+		if !l.zone[zi].isDST {
+			return zi
+		}
+	}
+
+	// Case 4.
+	return 0
+}
+
+// firstZoneUsed reports whether the first zone is used by some
+// transition.
+func (l *Location) firstZoneUsed() bool {
+	for _, tx := range l.tx {
+		if tx.index == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tzset takes a timezone string like the one found in the TZ environment
+// variable, the time of the last time zone transition expressed as seconds
+// since January 1, 1970 00:00:00 UTC, and a time expressed the same way.
+// We call this a tzset string since in C the function tzset reads TZ.
+// The return values are as for lookup, plus ok which reports whether the
+// parse succeeded.
+func tzset(s string, lastTxSec, sec int64) (name string, offset int, start, end int64, isDST, ok bool) {
+	var (
+		stdName, dstName     string
+		stdOffset, dstOffset int
+	)
+
+	stdName, s, ok = tzsetName(s)
+	if ok {
+		stdOffset, s, ok = tzsetOffset(s)
+	}
+	if !ok {
+		return "", 0, 0, 0, false, false
+	}
+
+	// The numbers in the tzset string are added to local time to get UTC,
+	// but our offsets are added to UTC to get local time,
+	// so we negate the number we see here.
+	stdOffset = -stdOffset
+
+	if len(s) == 0 || s[0] == ',' {
+		// No daylight savings time.
+		return stdName, stdOffset, lastTxSec, omega, false, true
+	}
+
+	dstName, s, ok = tzsetName(s)
+	if ok {
+		if len(s) == 0 || s[0] == ',' {
+			dstOffset = stdOffset + secondsPerHour
+		} else {
+			dstOffset, s, ok = tzsetOffset(s)
+			dstOffset = -dstOffset // as with stdOffset, above
+		}
+	}
+	if !ok {
+		return "", 0, 0, 0, false, false
+	}
+
+	if len(s) == 0 {
+		// Default DST rules per tzcode.
+		s = ",M3.2.0,M11.1.0"
+	}
+	// The TZ definition does not mention ';' here but tzcode accepts it.
+	if s[0] != ',' && s[0] != ';' {
+		return "", 0, 0, 0, false, false
+	}
+	s = s[1:]
+
+	var startRule, endRule rule
+	startRule, s, ok = tzsetRule(s)
+	if !ok || len(s) == 0 || s[0] != ',' {
+		return "", 0, 0, 0, false, false
+	}
+	s = s[1:]
+	endRule, s, ok = tzsetRule(s)
+	if !ok || len(s) > 0 {
+		return "", 0, 0, 0, false, false
+	}
+
+	// Compute start of year in seconds since Unix epoch,
+	// and seconds since then to get to sec.
+	year := time.Unix(sec, 0).UTC().Year()
+	ystart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	yend := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	ysec := sec - ystart
+
+	startSec := int64(tzruleTime(year, startRule, stdOffset))
+	endSec := int64(tzruleTime(year, endRule, dstOffset))
+
+	// Daylight saving time may reach into the UTC year before or after, as with
+	// permanent DST written "0/0,J365/25", so look at the neighbouring years too.
+	if startSec <= endSec {
+		start, end = alpha, omega
+		for y := year - 1; y <= year+1; y++ {
+			ys := time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+			dstStart := ys + int64(tzruleTime(y, startRule, stdOffset))
+			dstEnd := ys + int64(tzruleTime(y, endRule, dstOffset))
+			switch {
+			case dstStart <= sec && sec < dstEnd:
+				return dstName, dstOffset, dstStart, dstEnd, true, true
+			case dstEnd <= sec:
+				start = dstEnd
+			case sec < dstStart && end == omega:
+				end = dstStart
+			}
+		}
+		return stdName, stdOffset, start, end, false, true
+	}
+
+	dstIsDST, stdIsDST := true, false
+	// Note: this is a flipping of "DST" and "STD" while retaining the labels
+	// This happens in southern hemispheres. The labelling here thus is a little
+	// inconsistent with the goal.
+	if endSec < startSec {
+		startSec, endSec = endSec, startSec
+		stdName, dstName = dstName, stdName
+		stdOffset, dstOffset = dstOffset, stdOffset
+		stdIsDST, dstIsDST = dstIsDST, stdIsDST
+	}
+
+	// The start and end values that we return are accurate
+	// close to a daylight savings transition, but are otherwise
+	// just the start and end of the year.
+	if ysec < startSec {
+		return stdName, stdOffset, ystart, startSec + ystart, stdIsDST, true
+	} else if ysec >= endSec {
+		return stdName, stdOffset, endSec + ystart, yend, stdIsDST, true
+	} else {
+		return dstName, dstOffset, startSec + ystart, endSec + ystart, dstIsDST, true
+	}
+}
+
+// tzsetName returns the timezone name at the start of the tzset string s,
+// and the remainder of s, and reports whether the parsing is OK.
+func tzsetName(s string) (string, string, bool) {
+	if len(s) == 0 {
+		return "", "", false
+	}
+	if s[0] != '<' {
+		for i, r := range s {
+			switch r {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', ',', '-', '+':
+				if i < 3 {
+					return "", "", false
+				}
+				return s[:i], s[i:], true
+			}
+		}
+		if len(s) < 3 {
+			return "", "", false
+		}
+		return s, "", true
+	} else {
+		for i, r := range s {
+			if r == '>' {
+				return s[1:i], s[i+1:], true
+			}
+		}
+		return "", "", false
+	}
+}
+
+// tzsetOffset returns the timezone offset at the start of the tzset string s,
+// and the remainder of s, and reports whether the parsing is OK.
+// The timezone offset is returned as a number of seconds.
+func tzsetOffset(s string) (offset int, rest string, ok bool) {
+	if len(s) == 0 {
+		return 0, "", false
+	}
+	neg := false
+	if s[0] == '+' {
+		s = s[1:]
+	} else if s[0] == '-' {
+		s = s[1:]
+		neg = true
+	}
+
+	// The tzdata code permits values up to 24 * 7 here,
+	// although POSIX does not.
+	var hours int
+	hours, s, ok = tzsetNum(s, 0, 24*7)
+	if !ok {
+		return 0, "", false
+	}
+	off := hours * secondsPerHour
+	if len(s) == 0 || s[0] != ':' {
+		if neg {
+			off = -off
+		}
+		return off, s, true
+	}
+
+	var mins int
+	mins, s, ok = tzsetNum(s[1:], 0, 59)
+	if !ok {
+		return 0, "", false
+	}
+	off += mins * secondsPerMinute
+	if len(s) == 0 || s[0] != ':' {
+		if neg {
+			off = -off
+		}
+		return off, s, true
+	}
+
+	var secs int
+	secs, s, ok = tzsetNum(s[1:], 0, 59)
+	if !ok {
+		return 0, "", false
+	}
+	off += secs
+
+	if neg {
+		off = -off
+	}
+	return off, s, true
+}
+
+// ruleKind is the kinds of rules that can be seen in a tzset string.
+type ruleKind int
+
+const (
+	ruleJulian ruleKind = iota
+	ruleDOY
+	ruleMonthWeekDay
+)
+
+// rule is a rule read from a tzset string.
+type rule struct {
+	kind ruleKind
+	day  int
+	week int
+	mon  int
+	time int // transition time
+}
+
+// tzsetRule parses a rule from a tzset string.
+// It returns the rule, and the remainder of the string, and reports success.
+func tzsetRule(s string) (rule, string, bool) {
+	var r rule
+	if len(s) == 0 {
+		return rule{}, "", false
+	}
+	ok := false
+	if s[0] == 'J' {
+		var jday int
+		jday, s, ok = tzsetNum(s[1:], 1, 365)
+		if !ok {
+			return rule{}, "", false
+		}
+		r.kind = ruleJulian
+		r.day = jday
+	} else if s[0] == 'M' {
+		var mon int
+		mon, s, ok = tzsetNum(s[1:], 1, 12)
+		if !ok || len(s) == 0 || s[0] != '.' {
+			return rule{}, "", false
+
+		}
+		var week int
+		week, s, ok = tzsetNum(s[1:], 1, 5)
+		if !ok || len(s) == 0 || s[0] != '.' {
+			return rule{}, "", false
+		}
+		var day int
+		day, s, ok = tzsetNum(s[1:], 0, 6)
+		if !ok {
+			return rule{}, "", false
+		}
+		r.kind = ruleMonthWeekDay
+		r.day = day
+		r.week = week
+		r.mon = mon
+	} else {
+		var day int
+		day, s, ok = tzsetNum(s, 0, 365)
+		if !ok {
+			return rule{}, "", false
+		}
+		r.kind = ruleDOY
+		r.day = day
+	}
+
+	if len(s) == 0 || s[0] != '/' {
+		r.time = 2 * secondsPerHour // 2am is the default
+		return r, s, true
+	}
+
+	offset, s, ok := tzsetOffset(s[1:])
+	if !ok {
+		return rule{}, "", false
+	}
+	r.time = offset
+
+	return r, s, true
 }
 
-func DumpLocation(tzInfo *Location) {
-	fmt.Println("Name:", tzInfo.name)
-	fmt.Println("Zone[", len(tzInfo.zone), "]")
-	for i, zone := range tzInfo.zone {
-		fmt.Printf("  [%d]: %+v\n", i, zone)
+// tzsetNum parses a number from a tzset string.
+// It returns the number, and the remainder of the string, and reports success.
+// The number must be between min and max.
+func tzsetNum(s string, min, max int) (num int, rest string, ok bool) {
+	if len(s) == 0 {
+		return 0, "", false
+	}
+	num = 0
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			if i == 0 || num < min {
+				return 0, "", false
+			}
+			return num, s[i:], true
+		}
+		num *= 10
+		num += int(r) - '0'
+		if num > max {
+			return 0, "", false
+		}
 	}
-	fmt.Println("Transition[", len(tzInfo.tx), "]")
-	for i, tx := range tzInfo.tx {
-		fmt.Printf("  [%d]: %+v\n", i, tx)
+	if num < min {
+		return 0, "", false
+	}
+	return num, "", true
+}
+
+// tzruleTime takes a year, a rule, and a timezone offset,
+// and returns the number of seconds since the start of the year
+// that the rule takes effect.
+func tzruleTime(year int, r rule, off int) int {
+	var s int
+	switch r.kind {
+	case ruleJulian:
+		s = (r.day - 1) * secondsPerDay
+		if isLeap(year) && r.day >= 60 {
+			s += secondsPerDay
+		}
+	case ruleDOY:
+		s = r.day * secondsPerDay
+	case ruleMonthWeekDay:
+		// Zeller's Congruence.
+		m1 := (r.mon+9)%12 + 1
+		yy0 := year
+		if r.mon <= 2 {
+			yy0--
+		}
+		yy1 := yy0 / 100
+		yy2 := yy0 % 100
+		dow := ((26*m1-2)/10 + 1 + yy2 + yy2/4 + yy1/4 - 2*yy1) % 7
+		if dow < 0 {
+			dow += 7
+		}
+		// Now dow is the day-of-week of the first day of r.mon.
+		// Get the day-of-month of the first "dow" day.
+		d := r.day - dow
+		if d < 0 {
+			d += 7
+		}
+		for i := 1; i < r.week; i++ {
+			if d+7 >= daysIn(time.Month(r.mon), year) {
+				break
+			}
+			d += 7
+		}
+		d += daysBefore(time.Month(r.mon))
+		if isLeap(year) && r.mon > 2 {
+			d++
+		}
+		s = d * secondsPerDay
 	}
-	fmt.Println("Extend:", tzInfo.extend)
+
+	return s + r.time - off
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// daysIn returns the number of days in month m of year.
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// daysBefore returns the number of days in a non-leap year before month m.
+func daysBefore(m time.Month) int {
+	return time.Date(1970, m, 1, 0, 0, 0, 0, time.UTC).YearDay() - 1
 }
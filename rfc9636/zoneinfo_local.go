@@ -0,0 +1,207 @@
+package rfc9636
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ZoneSources are the zoneinfo directories searched for the zone named by TZ when TZDIR
+// is not set.
+var ZoneSources = []string{
+	"/usr/share/zoneinfo/",
+	"/usr/share/lib/zoneinfo/",
+	"/usr/lib/locale/TZ/",
+}
+
+// DefaultLocaltime is the file libc reads when TZ is not set.
+var DefaultLocaltime = "/etc/localtime"
+
+// The forms of the TZ variable that ResolveLocal tells apart.
+const (
+	LocalDefault = "unset" // TZ is not set, DefaultLocaltime is used
+	LocalEmpty   = "empty" // TZ is set but empty, or just ":", which means UTC
+	LocalPath    = "path"  // TZ is an absolute path, with or without a leading ':'
+	LocalName    = "name"  // TZ names a file in a zoneinfo directory, with or without a leading ':'
+	LocalPOSIX   = "posix" // TZ is a POSIX TZ string
+)
+
+// How ResolveLocal found the zone name of a LocalZone.
+const (
+	NameFromTZ      = "TZ"      // TZ gives the name
+	NameFromSymlink = "symlink" // the file is a symbolic link into a zoneinfo directory
+	NameFromContent = "content" // a zoneinfo directory has a file with the same contents
+)
+
+// LocalZone is the local time zone libc uses and how ResolveLocal found it.
+type LocalZone struct {
+	Location   *Location
+	TZ         string   // the TZ variable, when set
+	Form       string   // the form of TZ, such as LocalPath
+	File       string   // the TZif file read, none for UTC and POSIX strings
+	Name       string   // the zone name in the zoneinfo directories, "" if none was found
+	NameFrom   string   // how Name was found, such as NameFromSymlink
+	Candidates []string // the names of the files with the same contents, for NameFromContent
+	Problem    string   // why libc falls back to UTC, if it does
+}
+
+func (l *Location) get() *Location {
+	if l == nil {
+		return &utcLoc
+	}
+	if l == &localLoc {
+		localOnce.Do(initLocal)
+	}
+	return l
+}
+
+func initLocal() {
+	tz, set := os.LookupEnv("TZ")
+	sources := ZoneSources
+	if dir := os.Getenv("TZDIR"); dir != "" {
+		sources = []string{dir}
+	}
+	localLoc = *ResolveLocal(tz, set, sources).Location
+	localLoc.name = "Local"
+}
+
+// ResolveLocal returns the local time zone for the TZ variable tz, set tells whether it is
+// set at all, the way glibc finds it: without TZ DefaultLocaltime is read, an empty TZ means
+// UTC, and otherwise TZ, without a leading ':', is an absolute path, the name of a file in
+// one of the zoneinfo directories sources, or else a POSIX TZ string. When none of them
+// works out libc uses UTC, and Problem tells why. The zone name of a file read comes from
+// its path, its symbolic links or else a comparison of its contents with the files in sources.
+func ResolveLocal(tz string, set bool, sources []string) *LocalZone {
+	lz := &LocalZone{TZ: tz}
+	name := strings.TrimPrefix(tz, ":")
+	switch {
+	case !set:
+		lz.Form, lz.File = LocalDefault, DefaultLocaltime
+	case name == "":
+		lz.Form, lz.Location = LocalEmpty, UTC
+		return lz
+	case filepath.IsAbs(name):
+		lz.Form, lz.File = LocalPath, name
+	default:
+		for _, source := range sources {
+			if path := filepath.Join(source, name); fileExists(path) {
+				lz.Form, lz.File, lz.Name, lz.NameFrom = LocalName, path, name, NameFromTZ
+				break
+			}
+		}
+		if lz.File == "" {
+			// Like glibc, parse the name without its ':' when no file has it.
+			if loc, err := LocationFromTZ(name, name, 0, 0); err == nil {
+				lz.Form, lz.Location = LocalPOSIX, loc
+				return lz
+			}
+			lz.Form, lz.Location = LocalName, UTC
+			lz.Problem = fmt.Sprintf("%q is neither a zone in %s nor a POSIX TZ string", name, strings.Join(sources, ", "))
+			return lz
+		}
+	}
+
+	data, err := os.ReadFile(lz.File)
+	if err == nil {
+		lz.Location, err = LoadLocationFromTZData(lz.File, data)
+	}
+	if err != nil {
+		lz.Location, lz.Problem = UTC, err.Error()
+		return lz
+	}
+	if lz.Name == "" {
+		if name, ok := nameInSources(lz.File, sources); ok {
+			lz.Name, lz.NameFrom = strings.TrimPrefix(name, "posix/"), NameFromTZ
+		} else if name, ok := nameFromLinks(lz.File, sources); ok {
+			lz.Name, lz.NameFrom = name, NameFromSymlink
+		} else if lz.Candidates = namesFromContent(data, sources); len(lz.Candidates) > 0 {
+			lz.Name, lz.NameFrom = lz.Candidates[0], NameFromContent
+		}
+	}
+	if lz.Name != "" {
+		lz.Location.name = lz.Name
+	}
+	return lz
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// nameFromLinks follows the symbolic links from path and returns the name of the first
+// one that leads into one of the sources, without a posix/ prefix since those are the same.
+func nameFromLinks(path string, sources []string) (string, bool) {
+	for range 40 { // the limit of Linux on nested links
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", false
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		if name, ok := nameInSources(target, sources); ok {
+			return strings.TrimPrefix(name, "posix/"), true
+		}
+		path = target
+	}
+	return "", false
+}
+
+// nameInSources returns the name of path relative to the one of sources it is in.
+func nameInSources(path string, sources []string) (string, bool) {
+	for _, source := range sources {
+		roots := []string{filepath.Clean(source)}
+		if resolved, err := filepath.EvalSymlinks(source); err == nil && resolved != roots[0] {
+			roots = append(roots, resolved)
+		}
+		for _, root := range roots {
+			if rel, err := filepath.Rel(root, filepath.Clean(path)); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+				return rel, true
+			}
+		}
+	}
+	return "", false
+}
+
+// namesFromContent returns the names of the files in sources with contents data, Area/Location
+// names first. The posix/ and right/ subtrees, posixrules and localtime are left out.
+func namesFromContent(data []byte, sources []string) []string {
+	var names []string
+	for _, source := range sources {
+		root := filepath.Clean(source)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			switch {
+			case d.IsDir() && (rel == "posix" || rel == "right"):
+				return filepath.SkipDir
+			case !d.Type().IsRegular() || rel == "posixrules" || rel == "localtime":
+				return nil
+			}
+			if info, err := d.Info(); err != nil || info.Size() != int64(len(data)) {
+				return nil
+			}
+			if other, err := os.ReadFile(path); err == nil && bytes.Equal(other, data) && !slices.Contains(names, rel) {
+				names = append(names, rel)
+			}
+			return nil
+		})
+	}
+	slices.SortStableFunc(names, func(a, b string) int {
+		if aa, ba := strings.Contains(a, "/"), strings.Contains(b, "/"); aa != ba {
+			if aa {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return names
+}
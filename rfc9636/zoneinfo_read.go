@@ -209,7 +209,7 @@ func LoadLocationFromTZData(name string, data []byte) (*Location, error) {
 	abbrev := d.read(n[NChar])
 
 	// Leap-second time pairs
-	d.read(n[NLeap] * (size + 4))
+	leapdata := dataIO{d.read(n[NLeap] * (size + 4)), false}
 
 	// Whether tx times associated with local time types
 	// are specified as standard time or wall time.
@@ -298,6 +298,23 @@ func LoadLocationFromTZData(name string, data []byte) (*Location, error) {
 		}
 	}
 
+	var leaps []leapSecond
+	for range n[NLeap] {
+		var when int64
+		if !is64 {
+			n4, _ := leapdata.big4()
+			when = int64(int32(n4))
+		} else {
+			n8, _ := leapdata.big8()
+			when = int64(n8)
+		}
+		correction, ok := leapdata.big4()
+		if !ok {
+			return nil, errBadData
+		}
+		leaps = append(leaps, leapSecond{when: when, correction: int64(int32(correction))})
+	}
+
 	if len(tx) == 0 {
 		// Build fake transition to cover all time.
 		// This happens in fixed locations like "Etc/GMT0".
@@ -305,7 +322,7 @@ func LoadLocationFromTZData(name string, data []byte) (*Location, error) {
 	}
 
 	// Committed to succeed.
-	l := &Location{zone: zones, tx: tx, name: name, extend: extend}
+	l := &Location{zone: zones, tx: tx, name: name, extend: extend, leaps: leaps}
 
 	// Fill in the cache with information about right now,
 	// since that will be the most common lookup.
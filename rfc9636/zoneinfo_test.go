@@ -0,0 +1,325 @@
+package rfc9636
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testZoneDir = "/usr/share/zoneinfo"
+
+func loadTestLocation(t *testing.T, name string) *Location {
+	t.Helper()
+	l, err := LoadLocation(name, []string{testZoneDir})
+	if err != nil {
+		t.Skipf("zoneinfo %s not available: %v", name, err)
+	}
+	return l
+}
+
+func TestLookupMatchesTime(t *testing.T) {
+	zones := []string{"America/New_York", "Australia/Sydney", "Europe/Dublin", "Asia/Kolkata", "America/Santiago", "Africa/Casablanca"}
+	for _, name := range zones {
+		t.Run(name, func(t *testing.T) {
+			l := loadTestLocation(t, name)
+			goLoc, err := time.LoadLocation(name)
+			if err != nil {
+				t.Skip(err)
+			}
+			start := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+			stop := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+			for sec := start; sec < stop; sec += 7*secondsPerDay + 3607 {
+				tt, _, _ := l.Lookup(sec)
+				goName, goOffset := time.Unix(sec, 0).In(goLoc).Zone()
+				if tt.Name != goName || tt.Offset != goOffset {
+					t.Fatalf("%s at %d: got %s %d, want %s %d", name, sec, tt.Name, tt.Offset, goName, goOffset)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	l := loadTestLocation(t, "America/New_York")
+	from := time.Date(2037, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2039, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	want := []Transition{
+		{When: time.Date(2037, 3, 8, 7, 0, 0, 0, time.UTC).Unix(), Before: TimeType{"EST", -18000, false}, After: TimeType{"EDT", -14400, true}},
+		{When: time.Date(2037, 11, 1, 6, 0, 0, 0, time.UTC).Unix(), Before: TimeType{"EDT", -14400, true}, After: TimeType{"EST", -18000, false}},
+		{When: time.Date(2038, 3, 14, 7, 0, 0, 0, time.UTC).Unix(), Before: TimeType{"EST", -18000, false}, After: TimeType{"EDT", -14400, true}},
+		{When: time.Date(2038, 11, 7, 6, 0, 0, 0, time.UTC).Unix(), Before: TimeType{"EDT", -14400, true}, After: TimeType{"EST", -18000, false}},
+	}
+	got := l.Transitions(from, to)
+	if len(got) != len(want) {
+		t.Fatalf("got %d transitions %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transition %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	next := l.NextTransitions(want[1].When, 2)
+	if len(next) != 2 || next[0] != want[1] || next[1] != want[2] {
+		t.Errorf("NextTransitions: got %+v, want %+v", next, want[1:3])
+	}
+}
+
+func TestTransitionsPermanentDST(t *testing.T) {
+	for _, l := range []*Location{{
+		name:   "West",
+		zone:   []zone{{"-04", -14400, false}, {"-03", -10800, true}},
+		tx:     []zoneTrans{{when: 0, index: 1}},
+		extend: "<-04>4<-03>,0/0,J365/25",
+	}, {
+		// East of UTC the DST of one year starts in the UTC year before.
+		name:   "East",
+		zone:   []zone{{"AEST", 36000, false}, {"AEDT", 39600, true}},
+		tx:     []zoneTrans{{when: 0, index: 1}},
+		extend: "AEST-10AEDT,0/0,J365/25",
+	}, {
+		// Without a table the footer alone must still come to an end.
+		name:   "Footer",
+		zone:   []zone{{"-03", -10800, true}},
+		tx:     []zoneTrans{{when: alpha, index: 0}},
+		extend: "<-04>4<-03>,0/0,J365/25",
+	}} {
+		if got := l.Transitions(1, 5e9); len(got) != 0 {
+			t.Errorf("%s Transitions: got %+v, want none", l.name, got)
+		}
+		if got := l.NextTransitions(1, 1); len(got) != 0 {
+			t.Errorf("%s NextTransitions: got %+v, want none", l.name, got)
+		}
+	}
+
+	// A footer with DST rules and no table keeps changing year after year.
+	l := &Location{
+		name:   "Rules",
+		zone:   []zone{{"EST", -18000, false}},
+		tx:     []zoneTrans{{when: alpha, index: 0}},
+		extend: "EST5EDT,M3.2.0,M11.1.0",
+	}
+	if got := l.Transitions(0, 50*365*secondsPerDay); len(got) != 100 {
+		t.Errorf("Rules Transitions: got %d, want 100", len(got))
+	}
+}
+
+func TestDumpLocation(t *testing.T) {
+	l := loadTestLocation(t, "America/New_York")
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
+	var b strings.Builder
+	if err := DumpLocation(&b, l, from, to); err != nil {
+		t.Fatal(err)
+	}
+	want := "America/New_York  Sun Mar 10 06:59:59 2024 UT = Sun Mar 10 01:59:59 2024 EST isdst=0 gmtoff=-18000\n" +
+		"America/New_York  Sun Mar 10 07:00:00 2024 UT = Sun Mar 10 03:00:00 2024 EDT isdst=1 gmtoff=-14400\n"
+	if b.String() != want {
+		t.Errorf("DumpLocation =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	data, err := LoadTzinfoFromDir(testZoneDir, "Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	l := Inspect(data)
+	if len(l.Problems) != 0 || len(l.Blocks) != 2 || l.Footer == nil || l.Trailing != nil {
+		t.Fatalf("Inspect = %+v", l)
+	}
+	if l.Footer.Text != "CET-1CEST,M3.5.0,M10.5.0/3" || l.Footer.End() != len(data) {
+		t.Errorf("footer = %+v", l.Footer)
+	}
+	if got := l.Blocks[1].Types[0]; got.Abbr != "LMT" || got.UTOff != 3208 {
+		t.Errorf("type 0 = %+v", got)
+	}
+
+	for _, test := range []struct {
+		data    []byte
+		problem string
+	}{
+		{data[:300], "the v1 transition times at 0x002c need 572 bytes, 256 left"},
+		{append(slices.Clip(data), "junk"...), fmt.Sprintf("4 bytes of trailing data at 0x%04x", len(data))},
+		{append([]byte("TZxf"), data[4:]...), `the v1 header at 0x0000 starts with "TZxf", not "TZif"`},
+	} {
+		if l := Inspect(test.data); len(l.Problems) != 1 || l.Problems[0] != test.problem {
+			t.Errorf("Inspect problems = %q, want %q", l.Problems, test.problem)
+		}
+	}
+}
+
+func TestLocationFromTZ(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	for _, tz := range []string{"CET-1CEST,M3.5.0,M10.5.0/3", "AEST-10AEDT,M10.1.0,M4.1.0/3", "<-03>3<-02>,0/0,J365/25", "IST-5:30", "EST5EDT"} {
+		for _, fat := range []bool{false, true} {
+			for _, r := range [][2]int64{{0, 0}, {from, to}} {
+				l, err := LocationFromTZ("Test", tz, r[0], r[1])
+				if err != nil {
+					t.Fatalf("%s: %v", tz, err)
+				}
+				data, err := l.TZData(fat)
+				if err != nil {
+					t.Fatalf("%s: %v", tz, err)
+				}
+				got, err := LoadLocationFromTZData("Test", data)
+				if err != nil {
+					t.Fatalf("%s: %v", tz, err)
+				}
+				want := &Location{name: "Test", zone: []zone{{}}, tx: []zoneTrans{{when: alpha}}, extend: l.Extend()}
+				// The table starts in standard time, so a transition at from may be new.
+				if g, w := got.Transitions(from+1, 5e9), want.Transitions(from+1, 5e9); !slices.Equal(g, w) {
+					t.Errorf("%s (fat %v, range %v): got %d transitions, want %d", tz, fat, r, len(g), len(w))
+				}
+			}
+		}
+	}
+	if _, err := LocationFromTZ("Test", "CET", 0, 0); err == nil {
+		t.Error("LocationFromTZ accepted a TZ string without offset")
+	}
+}
+
+// TestTZDataSystem encodes the installed zones again and checks that nothing changes.
+func TestTZDataSystem(t *testing.T) {
+	for _, name := range []string{"Europe/Berlin", "America/New_York", "Australia/Sydney", "Africa/Casablanca", "America/Sao_Paulo", "Asia/Kolkata", "Etc/UTC"} {
+		l := loadTestLocation(t, name)
+		for _, fat := range []bool{false, true} {
+			data, err := l.TZData(fat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadLocationFromTZData(name, data)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			g, _, _ := got.Lookup(alpha)
+			w, _, _ := l.Lookup(alpha)
+			if g != w || !slices.Equal(got.Transitions(alpha, 5e9), l.Transitions(alpha, 5e9)) || got.Extend() != l.Extend() {
+				t.Errorf("%s (fat %v) changed when encoded", name, fat)
+			}
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	lo := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	hi := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	// The right/ zone has leap second records, which must survive with the correction at lo.
+	for _, name := range []string{"Europe/Berlin", "right/Europe/Berlin"} {
+		t.Run(name, func(t *testing.T) {
+			l := loadTestLocation(t, name)
+			for _, r := range [][2]int64{{lo, hi}, {lo, omega}, {alpha, hi}} {
+				for _, fat := range []bool{false, true} {
+					tr, err := l.Truncate(r[0], r[1])
+					if err != nil {
+						t.Fatal(err)
+					}
+					data, err := tr.TZData(fat)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got, err := LoadLocationFromTZData(l.Name(), data)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if err := l.Verify(got, r[0], min(r[1], 5e9)); err != nil {
+						t.Errorf("range %v: %v", r, err)
+					}
+					if before, _, _ := got.Lookup(r[0] - 1); r[0] != alpha && before != Unspecified {
+						t.Errorf("range %v: before lo in %+v", r, before)
+					}
+					if after, _, _ := got.Lookup(r[1]); r[1] != omega && (after != Unspecified || got.Extend() != "") {
+						t.Errorf("range %v: from hi on in %+v with footer %q", r, after, got.Extend())
+					}
+					if len(l.leaps) > 0 && (len(got.leaps) == 0 || r[0] != alpha && data[4] != '4') {
+						t.Errorf("range %v: %d leap seconds, version %q", r, len(got.leaps), data[4])
+					}
+				}
+			}
+			if _, err := l.Truncate(hi, lo); err == nil {
+				t.Error("Truncate accepted an empty range")
+			}
+		})
+	}
+}
+
+func TestSlim(t *testing.T) {
+	l := loadTestLocation(t, "America/New_York")
+	slim, err := l.Slim()
+	if err != nil {
+		t.Fatal(err)
+	}
+	end, _ := l.TableEnd()
+	slimEnd, ok := slim.TableEnd()
+	if !ok || slimEnd >= end {
+		t.Errorf("slim table ends at %d, the original at %d", slimEnd, end)
+	}
+	if err := l.Verify(slim, alpha, 5e9); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResolveLocal(t *testing.T) {
+	dir := t.TempDir()
+	zoneinfo := filepath.Join(dir, "zoneinfo")
+	paris, err := LocationFromTZ("Europe/Paris", "CET-1CEST,M3.5.0,M10.5.0/3", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := paris.TZData(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Europe/Paris", "MET", "posixrules"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(zoneinfo, name)), 0o755)
+		if err := os.WriteFile(filepath.Join(zoneinfo, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Symlink("Paris", filepath.Join(zoneinfo, "Europe/Monaco"))
+	os.Symlink(filepath.Join(zoneinfo, "Europe/Monaco"), filepath.Join(dir, "linked"))
+	os.WriteFile(filepath.Join(dir, "copied"), data, 0o644)
+	sources := []string{zoneinfo}
+
+	defer func(saved string) { DefaultLocaltime = saved }(DefaultLocaltime)
+	var tests = []struct {
+		tz       string
+		set      bool
+		form     string
+		name     string
+		nameFrom string
+		offset   int
+	}{
+		{"", false, LocalDefault, "Europe/Monaco", NameFromSymlink, 3600},
+		{"", true, LocalEmpty, "", "", 0},
+		{":", true, LocalEmpty, "", "", 0},
+		{":" + filepath.Join(dir, "copied"), true, LocalPath, "Europe/Paris", NameFromContent, 3600},
+		{filepath.Join(dir, "linked"), true, LocalPath, "Europe/Monaco", NameFromSymlink, 3600},
+		{":Europe/Paris", true, LocalName, "Europe/Paris", NameFromTZ, 3600},
+		{"MET", true, LocalName, "MET", NameFromTZ, 3600},
+		{"EST5EDT", true, LocalPOSIX, "", "", -5 * 3600},
+		{":EST5EDT", true, LocalPOSIX, "", "", -5 * 3600},
+		{":XYZ3", true, LocalPOSIX, "", "", -3 * 3600},
+		{"Nowhere/Zone", true, LocalName, "", "", 0},
+	}
+	DefaultLocaltime = filepath.Join(dir, "linked")
+	for _, tt := range tests {
+		lz := ResolveLocal(tt.tz, tt.set, sources)
+		tt0, _, _ := lz.Location.Lookup(1767225600) // 2026-01-01, standard time
+		if lz.Form != tt.form || lz.Name != tt.name || lz.NameFrom != tt.nameFrom || tt0.Offset != tt.offset {
+			t.Errorf("TZ %q set %v: got form %s name %q from %q offset %d, want %s %q %q %d (problem %q)",
+				tt.tz, tt.set, lz.Form, lz.Name, lz.NameFrom, tt0.Offset, tt.form, tt.name, tt.nameFrom, tt.offset, lz.Problem)
+		}
+		if (lz.Problem != "") != (tt.form == LocalName && tt.name == "") {
+			t.Errorf("TZ %q: got problem %q", tt.tz, lz.Problem)
+		}
+	}
+	if lz := ResolveLocal(filepath.Join(dir, "copied"), true, sources); !slices.Equal(lz.Candidates, []string{"Europe/Paris", "MET"}) {
+		t.Errorf("got candidates %v, want [Europe/Paris MET]", lz.Candidates)
+	}
+}
@@ -0,0 +1,387 @@
+package rfc9636
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/tzlist/posix/tzposix"
+)
+
+// maxTypes is the number of local time types a TZif data block can hold.
+const maxTypes = 256
+
+func (z zone) timeType() TimeType {
+	return TimeType{Name: z.name, Offset: z.offset, IsDST: z.isDST}
+}
+
+// NewLocation returns a Location that is in local time type first before the first of txs,
+// which must be in increasing order, and follows the POSIX TZ string extend after the last.
+// Only the When and After fields of txs are used.
+func NewLocation(name string, first TimeType, txs []Transition, extend string) (*Location, error) {
+	l, err := newLocation(name, first, txs, extend, false)
+	if err == nil && l.lookupFirstZone() != 0 {
+		// Readers that do not simply use type 0 before the first transition would pick
+		// another type, so give the first type a copy of its own that no transition uses.
+		l, err = newLocation(name, first, txs, extend, true)
+	}
+	return l, err
+}
+
+// newLeapLocation is NewLocation for data with the leap second records leaps.
+func newLeapLocation(name string, first TimeType, txs []Transition, extend string, leaps []leapSecond) (*Location, error) {
+	l, err := NewLocation(name, first, txs, extend)
+	if err != nil {
+		return nil, err
+	}
+	l.leaps = leaps
+	return l, nil
+}
+
+func newLocation(name string, first TimeType, txs []Transition, extend string, ownFirst bool) (*Location, error) {
+	l := &Location{name: name, zone: []zone{{first.Name, first.Offset, first.IsDST}}, extend: extend}
+	shared := 0
+	if ownFirst {
+		shared = 1
+	}
+	for i, tx := range txs {
+		if i > 0 && tx.When <= txs[i-1].When {
+			return nil, fmt.Errorf("transition %d at %d is not after the one before it", i, tx.When)
+		}
+		index := findZone(l.zone[shared:], tx.After.Name, tx.After.Offset, tx.After.IsDST) + shared
+		if index < shared {
+			if len(l.zone) == maxTypes {
+				return nil, fmt.Errorf("more than %d local time types", maxTypes)
+			}
+			index = len(l.zone)
+			l.zone = append(l.zone, zone{tx.After.Name, tx.After.Offset, tx.After.IsDST})
+		}
+		l.tx = append(l.tx, zoneTrans{when: tx.When, index: uint8(index)})
+	}
+	if len(l.tx) == 0 {
+		l.tx = []zoneTrans{{when: alpha, index: 0}}
+	}
+	return l, nil
+}
+
+// LocationFromTZ returns a Location that follows the POSIX TZ string tz. The transitions
+// in [from, to) are also written to its table for readers that ignore the footer, and
+// the Location is in standard time before them. With from >= to the table holds no more
+// than glibc needs to use the footer.
+func LocationFromTZ(name, tz string, from, to int64) (*Location, error) {
+	p, err := tzposix.Parse(tz)
+	if err != nil {
+		return nil, err
+	}
+	if p.HasDST() && !strings.Contains(tz, ",") {
+		tz += ",M3.2.0,M11.1.0" // the footer spells out the default rules
+	}
+	std := TimeType{Name: p.StdName, Offset: p.StdOffset}
+	rules := &Location{name: name, zone: []zone{{std.Name, std.Offset, false}}, tx: []zoneTrans{{when: alpha}}, extend: tz}
+	if _, _, _, _, _, ok := tzset(tz, alpha, 0); !ok {
+		return nil, fmt.Errorf("invalid POSIX TZ string %q", tz)
+	}
+
+	var txs []Transition
+	if from < to {
+		if tt, _, _ := rules.Lookup(from); tt != std {
+			txs = append(txs, Transition{When: from, Before: std, After: tt})
+		}
+		txs = append(txs, rules.Transitions(from+1, to)...)
+	}
+	if len(txs) == 0 && p.HasDST() {
+		// glibc ignores the footer of data without transitions, so keep the first one
+		// from 1970 on, as zic keeps the first one of the rules.
+		if tt, _, _ := rules.Lookup(0); tt != std {
+			txs = append(txs, Transition{When: 0, Before: std, After: tt})
+		} else {
+			txs = rules.NextTransitions(1, 1)
+		}
+	}
+	return NewLocation(name, std, txs, tz)
+}
+
+// TZData encodes l as TZif data: version 4 when its leap second table is truncated or
+// expires, version 3 when the footer needs the extensions of RFC 9636 section 3.3.1 and
+// version 2 otherwise. With fat the version 1 data block holds the transitions and leap
+// seconds that fit in 32 bits for readers that know no other, without it the block is
+// minimal as with zic -b slim.
+func (l *Location) TZData(fat bool) ([]byte, error) {
+	var txs []Transition
+	for _, tx := range l.tx {
+		if tx.when != alpha {
+			txs = append(txs, Transition{When: tx.when, After: l.zone[tx.index].timeType()})
+		}
+	}
+	first := l.zone[l.lookupFirstZone()].timeType()
+	n, err := newLeapLocation(l.name, first, txs, l.extend, l.leaps)
+	if err != nil {
+		return nil, err
+	}
+
+	version := max(footerVersion(l.extend), l.leapVersion())
+	var out bytes.Buffer
+	if fat {
+		// The version 1 block starts in the type in force at its first representable time.
+		first32, txs32 := first, []Transition(nil)
+		for _, tx := range txs {
+			if tx.When < math.MinInt32 {
+				first32 = tx.After
+			} else if tx.When <= math.MaxInt32 {
+				txs32 = append(txs32, tx)
+			}
+		}
+		var leaps32 []leapSecond
+		for _, ls := range l.leaps {
+			if ls.when >= math.MinInt32 && ls.when <= math.MaxInt32 {
+				leaps32 = append(leaps32, ls)
+			}
+		}
+		n32, err := newLeapLocation(l.name, first32, txs32, "", leaps32)
+		if err != nil {
+			return nil, err
+		}
+		n32.writeBlock(&out, version, 4)
+	} else {
+		writeHeader(&out, version, 0, 0, 1, 1)
+		out.Write(make([]byte, 6+1)) // one time type of offset 0 named ""
+	}
+	n.writeBlock(&out, version, 8)
+	fmt.Fprintf(&out, "\n%s\n", l.extend)
+	return out.Bytes(), nil
+}
+
+// footerVersion returns the TZif version the POSIX TZ string extend needs.
+func footerVersion(extend string) byte {
+	if tz, err := tzposix.Parse(extend); err == nil && tz.HasDST() {
+		for _, r := range []tzposix.Rule{tz.Start, tz.End} {
+			if r.Time < 0 || r.Time > 24*secondsPerHour {
+				return '3'
+			}
+		}
+	}
+	return '2'
+}
+
+// leapVersion returns the TZif version the leap second records of l need: 4 when the
+// first correction is not a single leap second, as after zic -r, or the last record
+// marks the expiry of the table.
+func (l *Location) leapVersion() byte {
+	n := len(l.leaps)
+	if n > 0 && (l.leaps[0].correction != 1 && l.leaps[0].correction != -1 ||
+		n > 1 && l.leaps[n-1].correction == l.leaps[n-2].correction) {
+		return '4'
+	}
+	return '2'
+}
+
+func writeHeader(out *bytes.Buffer, version byte, leapcnt, timecnt, typecnt, charcnt int) {
+	out.WriteString("TZif")
+	out.WriteByte(version)
+	out.Write(make([]byte, 15))
+	// No UT/local and standard/wall indicators.
+	for _, count := range []int{0, 0, leapcnt, timecnt, typecnt, charcnt} {
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(count)))
+	}
+}
+
+// writeBlock writes the header and data block of l with timeSize byte transition times.
+func (l *Location) writeBlock(out *bytes.Buffer, version byte, timeSize int) {
+	var tx []zoneTrans
+	if len(l.tx) > 0 && l.tx[0].when != alpha {
+		tx = l.tx
+	}
+	var chars []byte
+	desigidx := make([]byte, len(l.zone))
+	for i, z := range l.zone {
+		j := bytes.Index(chars, append([]byte(z.name), 0))
+		if j < 0 {
+			j = len(chars)
+			chars = append(append(chars, z.name...), 0)
+		}
+		desigidx[i] = byte(j)
+	}
+
+	writeHeader(out, version, len(l.leaps), len(tx), len(l.zone), len(chars))
+	for _, t := range tx {
+		writeTime(out, t.when, timeSize)
+	}
+	for _, t := range tx {
+		out.WriteByte(t.index)
+	}
+	for i, z := range l.zone {
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(z.offset))))
+		out.WriteByte(boolByte(z.isDST))
+		out.WriteByte(desigidx[i])
+	}
+	out.Write(chars)
+	for _, ls := range l.leaps {
+		writeTime(out, ls.when, timeSize)
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(ls.correction))))
+	}
+}
+
+func writeTime(out *bytes.Buffer, t int64, timeSize int) {
+	if timeSize == 4 {
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(t))))
+	} else {
+		out.Write(binary.BigEndian.AppendUint64(nil, uint64(t)))
+	}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Unspecified is the local time type RFC 9636 uses for the times that truncated data
+// does not cover.
+var Unspecified = TimeType{Name: "-00"}
+
+// Truncate returns l limited to the times in [lo, hi), like zic -r @lo/@hi. Before lo and
+// from hi on it is in the Unspecified type, and with hi bounded the footer is left out, so
+// the footer transitions before hi move into the table. Leap second records are kept as
+// far as the range needs them. Pass math.MinInt64 or math.MaxInt64 to leave a bound open.
+func (l *Location) Truncate(lo, hi int64) (*Location, error) {
+	if hi <= lo {
+		return nil, fmt.Errorf("empty range [%d, %d)", lo, hi)
+	}
+	leaps := l.truncatedLeaps(lo, hi)
+	first, _, _ := l.Lookup(alpha)
+	var txs []Transition
+	if lo != alpha {
+		first = Unspecified
+		at, _, _ := l.Lookup(lo)
+		txs = append(txs, Transition{When: lo, Before: Unspecified, After: at})
+	}
+	if hi == omega {
+		if end, ok := l.TableEnd(); ok && end > lo {
+			txs = append(txs, l.Transitions(lo+1, end+1)...)
+		}
+		return newLeapLocation(l.name, first, txs, l.extend, leaps)
+	}
+	txs = append(txs, l.Transitions(lo+1, hi)...)
+	at, _, _ := l.Lookup(hi - 1)
+	txs = append(txs, Transition{When: hi, Before: at, After: Unspecified})
+	return newLeapLocation(l.name, first, txs, "", leaps)
+}
+
+// truncatedLeaps returns the leap second records of l that data limited to [lo, hi) needs,
+// as zic -r keeps them: from the last one at or before lo on, so that the correction in
+// force at lo is known, and one more before that when readers that take a positive
+// correction for an inserted leap second would misread it.
+func (l *Location) truncatedLeaps(lo, hi int64) []leapSecond {
+	base := 0
+	for base+1 < len(l.leaps) && l.leaps[base+1].when <= lo {
+		base++
+	}
+	for base > 0 && (l.leaps[base-1].correction < l.leaps[base].correction) != (l.leaps[base].correction > 0) {
+		base--
+	}
+	end := len(l.leaps)
+	for end > base && l.leaps[end-1].when > hi {
+		end--
+	}
+	return slices.Clone(l.leaps[base:end])
+}
+
+// Slim returns l without the transitions at the end of its table that the footer gives
+// as well, like zic -b slim. As with zic one transition stays when the footer has daylight
+// saving time, since glibc ignores the footer of data without transitions.
+func (l *Location) Slim() (*Location, error) {
+	first, _, _ := l.Lookup(alpha)
+	end, ok := l.TableEnd()
+	if !ok {
+		return newLeapLocation(l.name, first, nil, l.extend, l.leaps)
+	}
+	txs := l.Transitions(alpha, end+1)
+	if l.extend == "" {
+		return newLeapLocation(l.name, first, txs, l.extend, l.leaps)
+	}
+	keep := 0
+	if tz, err := tzposix.Parse(l.extend); err == nil && tz.HasDST() {
+		keep = 1
+	}
+	// After the old table end both give what the footer says, a year more makes sure of it.
+	horizon := end + 366*secondsPerDay
+	n := len(txs)
+	for n > keep {
+		// Up to the transition before the one left out nothing changes.
+		from := int64(alpha)
+		if n >= 2 {
+			from = txs[n-2].When
+		}
+		slim, err := newLeapLocation(l.name, first, txs[:n-1], l.extend, l.leaps)
+		if err != nil || l.Verify(slim, from, horizon) != nil {
+			break
+		}
+		n--
+	}
+	return newLeapLocation(l.name, first, txs[:n], l.extend, l.leaps)
+}
+
+// Verify reports the first time in [from, to) at which m is in another local time type
+// than l or has another leap second correction.
+func (l *Location) Verify(m *Location, from, to int64) error {
+	lt, _, _ := l.Lookup(from)
+	mt, _, _ := m.Lookup(from)
+	if lt != mt {
+		return fmt.Errorf("at %d %s is in %+v, want %+v", from, m.name, mt, lt)
+	}
+	ltx, mtx := l.Transitions(from+1, to), m.Transitions(from+1, to)
+	for i := range max(len(ltx), len(mtx)) {
+		switch {
+		case i == len(mtx):
+			return fmt.Errorf("%s misses the transition at %d to %+v", m.name, ltx[i].When, ltx[i].After)
+		case i == len(ltx):
+			return fmt.Errorf("%s has an extra transition at %d to %+v", m.name, mtx[i].When, mtx[i].After)
+		case ltx[i] != mtx[i]:
+			return fmt.Errorf("%s has a transition at %d to %+v, want at %d to %+v",
+				m.name, mtx[i].When, mtx[i].After, ltx[i].When, ltx[i].After)
+		}
+	}
+	if lc, mc := l.leapCorrection(from), m.leapCorrection(from); lc != mc {
+		return fmt.Errorf("at %d %s has a leap second correction of %d, want %d", from, m.name, mc, lc)
+	}
+	lls, mls := l.leapsIn(from+1, to), m.leapsIn(from+1, to)
+	for i := range max(len(lls), len(mls)) {
+		switch {
+		case i == len(mls):
+			return fmt.Errorf("%s misses the leap second at %d to a correction of %d", m.name, lls[i].when, lls[i].correction)
+		case i == len(lls):
+			return fmt.Errorf("%s has an extra leap second at %d to a correction of %d", m.name, mls[i].when, mls[i].correction)
+		case lls[i] != mls[i]:
+			return fmt.Errorf("%s has a leap second at %d to a correction of %d, want at %d to %d",
+				m.name, mls[i].when, mls[i].correction, lls[i].when, lls[i].correction)
+		}
+	}
+	return nil
+}
+
+// leapCorrection returns the leap second correction in force at t.
+func (l *Location) leapCorrection(t int64) int64 {
+	var correction int64
+	for _, ls := range l.leaps {
+		if ls.when > t {
+			break
+		}
+		correction = ls.correction
+	}
+	return correction
+}
+
+// leapsIn returns the leap second records of l in [from, to).
+func (l *Location) leapsIn(from, to int64) []leapSecond {
+	var leaps []leapSecond
+	for _, ls := range l.leaps {
+		if ls.when >= from && ls.when < to {
+			leaps = append(leaps, ls)
+		}
+	}
+	return leaps
+}
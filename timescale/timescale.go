@@ -0,0 +1,116 @@
+// Package timescale converts between the UTC, TAI and GPS time scales and between the
+// "posix" timestamps of POSIX time, which leave leap seconds out, and the "right"
+// timestamps of the right/ zones, which count them, using a leap second table.
+//
+// All times are int64 seconds. UTC and posix times are POSIX times, TAI times count
+// from 1970-01-01 00:00:00 TAI and GPS times from the GPS epoch, 1980-01-06 00:00:00 UTC.
+// Before 1972, when UTC had no leap seconds yet, the TAI - UTC of 1972 is used.
+package timescale
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/tzlist/leapseconds"
+)
+
+// GPSEpoch is the start of GPS time in POSIX time.
+const GPSEpoch = 315964800
+
+// TAIMinusGPS is the fixed difference between TAI and GPS time.
+const TAIMinusGPS = 19
+
+// ErrExpired is returned, wrapped and together with the converted time, for times at or
+// after the expiration of the leap second table, which may miss leap seconds by then.
+var ErrExpired = errors.New("leap second table has expired")
+
+// A Converter converts times with a leap second table.
+type Converter struct {
+	table       *leapseconds.Table
+	occurrences []int64
+}
+
+// New returns a Converter for the leap second table t, whose leaps must be in increasing order.
+func New(t *leapseconds.Table) *Converter {
+	return &Converter{table: t, occurrences: t.Occurrences()}
+}
+
+// expired returns ErrExpired for the UTC time utc when the table does not cover it.
+func (c *Converter) expired(utc int64) error {
+	if c.table.Expires != 0 && utc >= c.table.Expires {
+		return fmt.Errorf("%w: %d is not before %d", ErrExpired, utc, c.table.Expires)
+	}
+	return nil
+}
+
+// correction returns the total of the leap seconds before the POSIX time posix.
+func (c *Converter) correction(posix int64) int {
+	i := sort.Search(len(c.table.Leaps), func(i int) bool { return c.table.Leaps[i].Time > posix })
+	if i == 0 {
+		return 0
+	}
+	return c.table.Leaps[i-1].Correction
+}
+
+// TAIMinusUTC returns TAI - UTC at the UTC time utc.
+func (c *Converter) TAIMinusUTC(utc int64) (int, error) {
+	return leapseconds.InitialTAIOffset + c.correction(utc), c.expired(utc)
+}
+
+// PosixToRight returns the right timestamp of the POSIX time posix.
+func (c *Converter) PosixToRight(posix int64) (int64, error) {
+	return posix + int64(c.correction(posix)), c.expired(posix)
+}
+
+// RightToPosix returns the POSIX time of the right timestamp right, like time2posix of
+// tzcode. An inserted leap second, 23:59:60, has the POSIX time of the 23:59:59 before
+// it, and leap reports whether right is one.
+func (c *Converter) RightToPosix(right int64) (posix int64, leap bool, err error) {
+	i := sort.Search(len(c.occurrences), func(i int) bool { return c.occurrences[i] > right })
+	correction := 0
+	if i > 0 {
+		correction = c.table.Leaps[i-1].Correction
+		previous := 0
+		if i > 1 {
+			previous = c.table.Leaps[i-2].Correction
+		}
+		leap = right == c.occurrences[i-1] && correction > previous
+	}
+	posix = right - int64(correction)
+	return posix, leap, c.expired(posix)
+}
+
+// UTCToTAI returns the TAI time of the UTC time utc.
+func (c *Converter) UTCToTAI(utc int64) (int64, error) {
+	right, err := c.PosixToRight(utc)
+	return right + leapseconds.InitialTAIOffset, err
+}
+
+// TAIToUTC returns the UTC time of the TAI time tai, and whether it is in a leap second
+// as for RightToPosix.
+func (c *Converter) TAIToUTC(tai int64) (utc int64, leap bool, err error) {
+	return c.RightToPosix(tai - leapseconds.InitialTAIOffset)
+}
+
+// UTCToGPS returns the GPS time of the UTC time utc.
+func (c *Converter) UTCToGPS(utc int64) (int64, error) {
+	tai, err := c.UTCToTAI(utc)
+	return TAIToGPS(tai), err
+}
+
+// GPSToUTC returns the UTC time of the GPS time gps, and whether it is in a leap second
+// as for RightToPosix.
+func (c *Converter) GPSToUTC(gps int64) (utc int64, leap bool, err error) {
+	return c.TAIToUTC(GPSToTAI(gps))
+}
+
+// TAIToGPS returns the GPS time of the TAI time tai, which needs no leap second table.
+func TAIToGPS(tai int64) int64 {
+	return tai - TAIMinusGPS - GPSEpoch
+}
+
+// GPSToTAI returns the TAI time of the GPS time gps.
+func GPSToTAI(gps int64) int64 {
+	return gps + GPSEpoch + TAIMinusGPS
+}
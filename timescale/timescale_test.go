@@ -0,0 +1,97 @@
+package timescale
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tzlist/leapseconds"
+)
+
+// leapDays are the days that ended in a leap second up to the 2025 tables.
+const leapDays = "1972 Jun 30;1972 Dec 31;1973 Dec 31;1974 Dec 31;1975 Dec 31;1976 Dec 31;1977 Dec 31;" +
+	"1978 Dec 31;1979 Dec 31;1981 Jun 30;1982 Jun 30;1983 Jun 30;1985 Jun 30;1987 Dec 31;1989 Dec 31;" +
+	"1990 Dec 31;1992 Jun 30;1993 Jun 30;1994 Jun 30;1995 Dec 31;1997 Jun 30;1998 Dec 31;2005 Dec 31;" +
+	"2008 Dec 31;2012 Jun 30;2015 Jun 30;2016 Dec 31"
+
+func converter(t *testing.T) *Converter {
+	var b strings.Builder
+	for _, day := range strings.Split(leapDays, ";") {
+		b.WriteString("Leap " + day + " 23:59:60 + S\n")
+	}
+	b.WriteString("#expires 1782604800\n")
+	table, err := leapseconds.ParseLeapseconds(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(table)
+}
+
+func TestConversions(t *testing.T) {
+	c := converter(t)
+	var tests = []struct {
+		utc, tai, gps int64
+		taiMinusUTC   int
+	}{
+		{0, 10, -315964809, 10},
+		{GPSEpoch, GPSEpoch + 19, 0, 19},
+		{78796799, 78796809, -237168010, 10},
+		{78796800, 78796811, -237168008, 11},
+		{1483228800, 1483228837, 1167264018, 37},
+	}
+	for _, tt := range tests {
+		if got, err := c.TAIMinusUTC(tt.utc); got != tt.taiMinusUTC || err != nil {
+			t.Errorf("TAIMinusUTC(%d) = %d, %v, want %d", tt.utc, got, err, tt.taiMinusUTC)
+		}
+		if got, err := c.UTCToTAI(tt.utc); got != tt.tai || err != nil {
+			t.Errorf("UTCToTAI(%d) = %d, %v, want %d", tt.utc, got, err, tt.tai)
+		}
+		if got, err := c.UTCToGPS(tt.utc); got != tt.gps || err != nil {
+			t.Errorf("UTCToGPS(%d) = %d, %v, want %d", tt.utc, got, err, tt.gps)
+		}
+		if got, leap, err := c.GPSToUTC(tt.gps); got != tt.utc || leap || err != nil {
+			t.Errorf("GPSToUTC(%d) = %d, %v, %v, want %d", tt.gps, got, leap, err, tt.utc)
+		}
+	}
+}
+
+func TestRightToPosix(t *testing.T) {
+	c := converter(t)
+	// 2016-12-31 23:59:59, 23:59:60 and 2017-01-01 00:00:00 in right/UTC.
+	var tests = []struct {
+		right, posix int64
+		leap         bool
+	}{
+		{1483228825, 1483228799, false},
+		{1483228826, 1483228799, true},
+		{1483228827, 1483228800, false},
+		{78796799, 78796799, false},
+		{78796800, 78796799, true},
+		{78796801, 78796800, false},
+	}
+	for _, tt := range tests {
+		posix, leap, err := c.RightToPosix(tt.right)
+		if posix != tt.posix || leap != tt.leap || err != nil {
+			t.Errorf("RightToPosix(%d) = %d, %v, %v, want %d, %v", tt.right, posix, leap, err, tt.posix, tt.leap)
+		}
+		if !tt.leap {
+			if right, _ := c.PosixToRight(tt.posix); right != tt.right {
+				t.Errorf("PosixToRight(%d) = %d, want %d", tt.posix, right, tt.right)
+			}
+		}
+	}
+}
+
+func TestExpired(t *testing.T) {
+	c := converter(t)
+	tai, err := c.UTCToTAI(1782604800)
+	if !errors.Is(err, ErrExpired) {
+		t.Errorf("got %v, want ErrExpired", err)
+	}
+	if tai != 1782604837 {
+		t.Errorf("got %d, want the time with the last known offset", tai)
+	}
+	if _, err := c.UTCToTAI(1782604799); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
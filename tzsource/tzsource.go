@@ -0,0 +1,657 @@
+// Package tzsource parses the zic input format of the tz database: the source files
+// such as northamerica, europe and backward, and the compact tzdata.zi generated from them.
+// See the zic(8) manual page for the format.
+package tzsource
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinYear and MaxYear stand for the "minimum" and "maximum" years of a rule.
+const (
+	MinYear = math.MinInt32
+	MaxYear = math.MaxInt32
+)
+
+// TimeKind tells which clock a time of day is measured on.
+type TimeKind int
+
+const (
+	WallClock TimeKind = iota // local time in effect, the default or suffix w
+	Standard                  // local standard time, suffix s
+	Universal                 // UT, suffix u, g or z
+)
+
+func (k TimeKind) String() string {
+	switch k {
+	case Standard:
+		return "s"
+	case Universal:
+		return "u"
+	}
+	return "w"
+}
+
+// Time is a time of day or an offset, in seconds. Hours may exceed 24 and may be negative.
+type Time struct {
+	Seconds int64
+	Kind    TimeKind
+}
+
+// DayKind tells how the day of a rule or of an until field is given.
+type DayKind int
+
+const (
+	DayOfMonth        DayKind = iota // a fixed day such as 5
+	LastWeekday                      // lastSun
+	WeekdayOnOrAfter                 // Sun>=8
+	WeekdayOnOrBefore                // Sun<=25
+)
+
+// Day is the ON field of a rule or the day of an until field.
+type Day struct {
+	Kind    DayKind
+	Weekday time.Weekday // unused for DayOfMonth
+	Day     int          // unused for LastWeekday
+}
+
+// Date returns the day of month it falls on in the given year and month.
+func (d Day) Date(year int, month time.Month) int {
+	switch d.Kind {
+	case LastWeekday:
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		return last.Day() - (int(last.Weekday())-int(d.Weekday)+7)%7
+	case WeekdayOnOrAfter:
+		wd := time.Date(year, month, d.Day, 0, 0, 0, 0, time.UTC).Weekday()
+		return d.Day + (int(d.Weekday)-int(wd)+7)%7
+	case WeekdayOnOrBefore:
+		wd := time.Date(year, month, d.Day, 0, 0, 0, 0, time.UTC).Weekday()
+		return d.Day - (int(wd)-int(d.Weekday)+7)%7
+	}
+	return d.Day
+}
+
+// A Rule is one line of a named set of daylight saving rules.
+type Rule struct {
+	Name    string
+	From    int // MinYear for "minimum"
+	To      int // MaxYear for "maximum"
+	Month   time.Month
+	On      Day
+	At      Time
+	Save    int64  // seconds added to standard time
+	IsDST   bool   // Save is nonzero, unless overridden by an s or d suffix
+	Letters string // the variable part of the zone abbreviation, "" for "-"
+}
+
+// Until is the instant a zone line stops being in effect, in the local time of that line.
+type Until struct {
+	Year  int
+	Month time.Month
+	Day   Day
+	Time  Time
+}
+
+// A ZoneLine is the Zone line or one of its continuation lines.
+type ZoneLine struct {
+	StdOff int64  // seconds east of UT
+	Rules  string // the name of a rule set, "" for "-" or a fixed saving
+	Save   int64  // the fixed saving when Rules is "" and HasSave is set
+	// HasSave is set when the RULES field is an amount of time rather than a name or "-".
+	HasSave bool
+	Format  string // the abbreviation format, such as "E%sT", "CST/CDT" or "%z"
+	Until   *Until // nil on the last line of the zone
+}
+
+// A Zone is the history of a named time zone.
+type Zone struct {
+	Name  string
+	Lines []ZoneLine
+}
+
+// A Link makes Name an alternative name for Target.
+type Link struct {
+	Target string
+	Name   string
+}
+
+// Data is the combined contents of one or more source files.
+type Data struct {
+	Version string // from the "# version" header of tzdata.zi
+	Zones   []Zone
+	Rules   map[string][]Rule // by rule set name, in input order
+	Links   []Link
+}
+
+// ReadFile parses the source file at path.
+func ReadFile(path string) (*Data, error) {
+	d := &Data{}
+	return d, d.ReadFile(path)
+}
+
+// ReadFile parses the source file at path and adds its records to d.
+func (d *Data) ReadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return d.Parse(f, path)
+}
+
+// Parse reads source lines from r and adds their records to d. name is used in errors.
+func (d *Data) Parse(r io.Reader, name string) error {
+	if d.Rules == nil {
+		d.Rules = make(map[string][]Rule)
+	}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	continuation := false
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		if version, found := strings.CutPrefix(text, "# version "); found && d.Version == "" {
+			d.Version = strings.TrimSpace(version)
+		}
+		fields, err := splitFields(text)
+		if err == nil && len(fields) > 0 {
+			continuation, err = d.parseLine(fields, continuation)
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if continuation {
+		return fmt.Errorf("%s: missing zone continuation line at end of file", name)
+	}
+	return nil
+}
+
+// parseLine adds the record of one line and reports whether a continuation line must follow.
+func (d *Data) parseLine(fields []string, continuation bool) (bool, error) {
+	if continuation {
+		zl, err := parseZoneLine(fields)
+		if err != nil {
+			return true, err
+		}
+		zone := &d.Zones[len(d.Zones)-1]
+		zone.Lines = append(zone.Lines, zl)
+		return zl.Until != nil, nil
+	}
+
+	kind, ok := lookupWord(fields[0], lineKinds)
+	if !ok {
+		if _, ok = lookupWord(fields[0], leapLineKinds); ok {
+			return false, nil // leap second lines are handled with the leap second table
+		}
+		return false, fmt.Errorf("unknown line type %q", fields[0])
+	}
+	switch kind {
+	case 0:
+		rule, err := parseRule(fields)
+		if err != nil {
+			return false, err
+		}
+		d.Rules[rule.Name] = append(d.Rules[rule.Name], rule)
+	case 1:
+		if len(fields) < 5 {
+			return false, fmt.Errorf("zone line has %d fields, needs at least 5", len(fields))
+		}
+		zl, err := parseZoneLine(fields[2:])
+		if err != nil {
+			return false, err
+		}
+		d.Zones = append(d.Zones, Zone{Name: fields[1], Lines: []ZoneLine{zl}})
+		return zl.Until != nil, nil
+	case 2:
+		if len(fields) != 3 {
+			return false, fmt.Errorf("link line has %d fields, needs 3", len(fields))
+		}
+		d.Links = append(d.Links, Link{Target: fields[1], Name: fields[2]})
+	}
+	return false, nil
+}
+
+// Compact reports whether d was read from tzdata.zi. zishrink shortens the rule names
+// there, so US reads u and NYC reads NY; only the source files have the full names.
+func (d *Data) Compact() bool {
+	return d.Version != ""
+}
+
+// Zone returns the zone with the given name.
+func (d *Data) Zone(name string) (Zone, bool) {
+	for _, z := range d.Zones {
+		if z.Name == name {
+			return z, true
+		}
+	}
+	return Zone{}, false
+}
+
+// RuleNames returns the names of the rule sets a zone uses, in order of first use.
+func (z Zone) RuleNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, zl := range z.Lines {
+		if zl.Rules != "" && !seen[zl.Rules] {
+			seen[zl.Rules] = true
+			names = append(names, zl.Rules)
+		}
+	}
+	return names
+}
+
+var (
+	lineKinds     = []string{"Rule", "Zone", "Link"}
+	leapLineKinds = []string{"Leap", "Expires"}
+	monthNames    = []string{"January", "February", "March", "April", "May", "June", "July",
+		"August", "September", "October", "November", "December"}
+	weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+	yearWords    = []string{"minimum", "maximum", "only"}
+)
+
+// lookupWord finds word in table like zic does: case-insensitively, as a whole word
+// or as an unambiguous prefix. It returns the index in table.
+func lookupWord(word string, table []string) (int, bool) {
+	if word == "" {
+		return 0, false
+	}
+	found := -1
+	for i, entry := range table {
+		if strings.EqualFold(word, entry) {
+			return i, true
+		}
+		if len(word) < len(entry) && strings.EqualFold(word, entry[:len(word)]) {
+			if found >= 0 {
+				return 0, false
+			}
+			found = i
+		}
+	}
+	return found, found >= 0
+}
+
+// splitFields splits a line into whitespace separated fields, honoring double quotes
+// and dropping the comment that starts with #.
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '#':
+			return fields, nil
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		}
+		var field strings.Builder
+		for i < len(line) && !strings.ContainsRune(" \t\r\f\v#", rune(line[i])) {
+			if line[i] == '"' {
+				end := strings.IndexByte(line[i+1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("odd number of quotation marks")
+				}
+				field.WriteString(line[i+1 : i+1+end])
+				i += end + 2
+				continue
+			}
+			field.WriteByte(line[i])
+			i++
+		}
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// parseRule parses "Rule NAME FROM TO - IN ON AT SAVE LETTER/S".
+func parseRule(fields []string) (Rule, error) {
+	if len(fields) != 10 {
+		return Rule{}, fmt.Errorf("rule line has %d fields, needs 10", len(fields))
+	}
+	r := Rule{Name: fields[1]}
+	var err error
+	if r.From, err = parseYear(fields[2], false); err != nil {
+		return r, err
+	}
+	if r.To, err = parseYear(fields[3], true); err != nil {
+		return r, err
+	}
+	if r.To == 0 { // only
+		r.To = r.From
+	}
+	if r.From > r.To {
+		return r, fmt.Errorf("rule %s starting year %d is after ending year %d", r.Name, r.From, r.To)
+	}
+	if fields[4] != "-" && fields[4] != "" {
+		return r, fmt.Errorf("rule %s has unsupported TYPE %q", r.Name, fields[4])
+	}
+	if r.Month, err = parseMonth(fields[5]); err != nil {
+		return r, err
+	}
+	if r.On, err = ParseDay(fields[6]); err != nil {
+		return r, err
+	}
+	if r.At, err = ParseTime(fields[7]); err != nil {
+		return r, err
+	}
+	if r.Save, r.IsDST, err = parseSave(fields[8]); err != nil {
+		return r, err
+	}
+	if fields[9] != "-" {
+		r.Letters = fields[9]
+	}
+	return r, nil
+}
+
+// parseYear parses a year or one of "minimum", "maximum" and, when allowOnly, "only",
+// which is returned as 0 for the caller to replace with the FROM year.
+func parseYear(s string, allowOnly bool) (int, error) {
+	if i, ok := lookupWord(s, yearWords); ok {
+		switch {
+		case i == 0:
+			return MinYear, nil
+		case i == 1:
+			return MaxYear, nil
+		case allowOnly:
+			return 0, nil
+		}
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil || year == 0 {
+		return 0, fmt.Errorf("invalid year %q", s)
+	}
+	return year, nil
+}
+
+func parseMonth(s string) (time.Month, error) {
+	i, ok := lookupWord(s, monthNames)
+	if !ok {
+		return 0, fmt.Errorf("invalid month name %q", s)
+	}
+	return time.Month(i + 1), nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	i, ok := lookupWord(s, weekdayNames)
+	if !ok {
+		return 0, fmt.Errorf("invalid weekday name %q", s)
+	}
+	return time.Weekday(i), nil
+}
+
+// ParseDay parses an ON field: "5", "lastSun", "Sun>=8" or "Sun<=25".
+func ParseDay(s string) (Day, error) {
+	if len(s) > 4 && strings.EqualFold(s[:4], "last") {
+		wd, err := parseWeekday(s[4:])
+		return Day{Kind: LastWeekday, Weekday: wd}, err
+	}
+	for _, op := range []struct {
+		sep  string
+		kind DayKind
+	}{{">=", WeekdayOnOrAfter}, {"<=", WeekdayOnOrBefore}} {
+		if name, number, found := strings.Cut(s, op.sep); found {
+			wd, err := parseWeekday(name)
+			if err != nil {
+				return Day{}, err
+			}
+			day, err := strconv.Atoi(number)
+			if err != nil || day < 1 || day > 31 {
+				return Day{}, fmt.Errorf("invalid day of month in %q", s)
+			}
+			return Day{Kind: op.kind, Weekday: wd, Day: day}, nil
+		}
+	}
+	day, err := strconv.Atoi(s)
+	if err != nil || day < 1 || day > 31 {
+		return Day{}, fmt.Errorf("invalid day of month %q", s)
+	}
+	return Day{Kind: DayOfMonth, Day: day}, nil
+}
+
+// ParseTime parses a time of day or an offset such as "2:00", "-1", "25:00", "1:00:00s"
+// or "0u". "-" is zero. Fractional seconds are rounded to the nearest second.
+func ParseTime(s string) (Time, error) {
+	t := Time{}
+	if s == "-" {
+		return t, nil
+	}
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'w':
+			s = s[:n-1]
+		case 's':
+			t.Kind, s = Standard, s[:n-1]
+		case 'u', 'g', 'z':
+			t.Kind, s = Universal, s[:n-1]
+		}
+	}
+	seconds, err := parseHMS(s)
+	t.Seconds = seconds
+	return t, err
+}
+
+// parseHMS parses [-]hh[:mm[:ss[.fraction]]].
+func parseHMS(s string) (int64, error) {
+	invalid := fmt.Errorf("invalid time %q", s)
+	sign := int64(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	}
+	parts := strings.Split(s, ":")
+	if s == "" || len(parts) > 3 {
+		return 0, invalid
+	}
+	var seconds int64
+	for i, part := range parts {
+		fraction := ""
+		if i == 2 {
+			part, fraction, _ = strings.Cut(part, ".")
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 || (i > 0 && n > 59) {
+			return 0, invalid
+		}
+		seconds = seconds*60 + n
+		if fraction != "" {
+			if strings.Trim(fraction, "0123456789") != "" {
+				return 0, invalid
+			}
+			if fraction[0] >= '5' {
+				seconds++
+			}
+		}
+	}
+	for i := len(parts); i < 3; i++ {
+		seconds *= 60
+	}
+	return sign * seconds, nil
+}
+
+// parseSave parses a SAVE field. A trailing s marks standard time and a trailing d
+// daylight saving time; otherwise any nonzero amount is daylight saving time.
+func parseSave(s string) (int64, bool, error) {
+	isDST, explicit := false, false
+	if n := len(s); n > 0 && (s[n-1] == 's' || s[n-1] == 'd') {
+		isDST, explicit, s = s[n-1] == 'd', true, s[:n-1]
+	}
+	seconds, err := parseHMS(s)
+	if s == "-" {
+		seconds, err = 0, nil
+	}
+	if !explicit {
+		isDST = seconds != 0
+	}
+	return seconds, isDST, err
+}
+
+// parseZoneLine parses "STDOFF RULES FORMAT [UNTIL]", the fields after the zone name.
+func parseZoneLine(fields []string) (ZoneLine, error) {
+	if len(fields) < 3 || len(fields) > 7 {
+		return ZoneLine{}, fmt.Errorf("zone line has %d fields, needs 3 to 7", len(fields))
+	}
+	var zl ZoneLine
+	var err error
+	if zl.StdOff, err = parseHMS(fields[0]); err != nil {
+		return zl, err
+	}
+	switch rules := fields[1]; {
+	case rules == "-":
+	case rules[0] == '-' || (rules[0] >= '0' && rules[0] <= '9'):
+		if zl.Save, _, err = parseSave(rules); err != nil {
+			return zl, err
+		}
+		zl.HasSave = true
+	default:
+		zl.Rules = rules
+	}
+	zl.Format = fields[2]
+	if len(fields) > 3 {
+		if zl.Until, err = parseUntil(fields[3:]); err != nil {
+			return zl, err
+		}
+	}
+	return zl, nil
+}
+
+// parseUntil parses "YEAR [MONTH [DAY [TIME]]]".
+func parseUntil(fields []string) (*Until, error) {
+	u := &Until{Month: time.January, Day: Day{Kind: DayOfMonth, Day: 1}}
+	year, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid until year %q", fields[0])
+	}
+	u.Year = year
+	if len(fields) > 1 {
+		if u.Month, err = parseMonth(fields[1]); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) > 2 {
+		if u.Day, err = ParseDay(fields[2]); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) > 3 {
+		if u.Time, err = ParseTime(fields[3]); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// String formats the time in zic syntax, such as "2:00", "-1:00" or "1:00:00s".
+func (t Time) String() string {
+	suffix := ""
+	if t.Kind != WallClock {
+		suffix = t.Kind.String()
+	}
+	return FormatOffset(t.Seconds) + suffix
+}
+
+// FormatOffset formats seconds as [-]h:mm[:ss], the way zic input writes offsets.
+func FormatOffset(seconds int64) string {
+	sign := ""
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	if seconds%60 != 0 {
+		return fmt.Sprintf("%s%d:%02d:%02d", sign, seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, seconds/3600, seconds/60%60)
+}
+
+// String formats the day in zic syntax, such as "5", "lastSun" or "Sun>=8".
+func (d Day) String() string {
+	weekday := d.Weekday.String()[:3]
+	switch d.Kind {
+	case LastWeekday:
+		return "last" + weekday
+	case WeekdayOnOrAfter:
+		return fmt.Sprintf("%s>=%d", weekday, d.Day)
+	case WeekdayOnOrBefore:
+		return fmt.Sprintf("%s<=%d", weekday, d.Day)
+	}
+	return strconv.Itoa(d.Day)
+}
+
+// String formats the until fields in zic syntax, such as "1883 Nov 18 17:00u",
+// leaving out the trailing fields that have their default values.
+func (u Until) String() string {
+	fields := []string{strconv.Itoa(u.Year), u.Month.String()[:3], u.Day.String(), u.Time.String()}
+	n := len(fields)
+	if u.Time == (Time{}) {
+		n--
+		if u.Day == (Day{Kind: DayOfMonth, Day: 1}) {
+			n--
+			if u.Month == time.January {
+				n--
+			}
+		}
+	}
+	return strings.Join(fields[:n], " ")
+}
+
+// String formats the rule as a zic Rule line.
+func (r Rule) String() string {
+	year := func(y int) string {
+		switch y {
+		case MinYear:
+			return "min"
+		case MaxYear:
+			return "max"
+		}
+		return strconv.Itoa(y)
+	}
+	to := year(r.To)
+	if r.To == r.From {
+		to = "only"
+	}
+	save := FormatOffset(r.Save)
+	if r.IsDST != (r.Save != 0) {
+		save += map[bool]string{true: "d", false: "s"}[r.IsDST]
+	}
+	letters := r.Letters
+	if letters == "" {
+		letters = "-"
+	}
+	return fmt.Sprintf("Rule\t%s\t%s\t%s\t-\t%s\t%s\t%s\t%s\t%s",
+		r.Name, year(r.From), to, r.Month.String()[:3], r.On, r.At, save, letters)
+}
+
+// String formats the line as the fields of a zic Zone line after the zone name.
+func (zl ZoneLine) String() string {
+	rules := "-"
+	if zl.Rules != "" {
+		rules = zl.Rules
+	} else if zl.HasSave {
+		rules = FormatOffset(zl.Save)
+	}
+	line := fmt.Sprintf("%s\t%s\t%s", FormatOffset(zl.StdOff), rules, zl.Format)
+	if zl.Until != nil {
+		line += "\t" + zl.Until.String()
+	}
+	return line
+}
+
+// String formats the zone as a zic Zone line and its continuation lines.
+func (z Zone) String() string {
+	var b strings.Builder
+	for i, zl := range z.Lines {
+		if i == 0 {
+			fmt.Fprintf(&b, "Zone\t%s\t%s", z.Name, zl)
+		} else {
+			fmt.Fprintf(&b, "\n\t\t\t%s", zl)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package tzsource
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const northamerica = `
+# Rule	NAME	FROM	TO	-	IN	ON	AT	SAVE	LETTER/S
+Rule	US	1918	1919	-	Mar	lastSun	2:00	1:00	D
+Rule	US	1918	1919	-	Oct	lastSun	2:00	0	S
+Rule	US	2007	max	-	Mar	Sun>=8	2:00	1:00	D
+Rule	US	2007	max	-	Nov	Sun>=1	2:00	0	S
+# Zone	NAME		STDOFF	RULES	FORMAT	[UNTIL]
+Zone America/New_York	-4:56:02 -	LMT	1883 Nov 18 17:00u
+			-5:00	US	E%sT	1920
+			-5:00	NY	E%sT	1942
+			-5:00	US	E%sT	1946
+			-5:00	NY	E%sT	1967
+			-5:00	US	E%sT
+Link	America/New_York	US/Eastern	# backward
+`
+
+// tzdata.zi abbreviates keywords and renames rule sets.
+const compact = `# version 2025b
+R u 1918 1919 - Mar lastSu 2 1 D
+R u 2007 ma - N Su>=1 2 0 S
+R E 1981 ma - Mar lastSu 1u 1 S
+Z Europe/Dublin -0:25:21 - LMT 1880 Au 2
+-0:25:21 - DMT 1916 May 21 2s
+1 E IST/GMT
+L Europe/Dublin Eire
+`
+
+func TestParse(t *testing.T) {
+	d := &Data{}
+	if err := d.Parse(strings.NewReader(northamerica), "northamerica"); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Rules["US"]) != 4 || len(d.Zones) != 1 || len(d.Links) != 1 {
+		t.Fatalf("got %d US rules, %d zones, %d links", len(d.Rules["US"]), len(d.Zones), len(d.Links))
+	}
+	r := d.Rules["US"][2]
+	if r.From != 2007 || r.To != MaxYear || r.Month != time.March || r.On != (Day{WeekdayOnOrAfter, time.Sunday, 8}) ||
+		r.At != (Time{7200, WallClock}) || r.Save != 3600 || !r.IsDST || r.Letters != "D" {
+		t.Errorf("rule = %+v", r)
+	}
+	z, ok := d.Zone("America/New_York")
+	if !ok || len(z.Lines) != 6 {
+		t.Fatalf("zone = %+v", z)
+	}
+	if first := z.Lines[0]; first.StdOff != -17762 || first.Rules != "" ||
+		*first.Until != (Until{1883, time.November, Day{Day: 18}, Time{61200, Universal}}) {
+		t.Errorf("first line = %+v until %+v", first, first.Until)
+	}
+	if names := z.RuleNames(); strings.Join(names, ",") != "US,NY" {
+		t.Errorf("RuleNames = %v", names)
+	}
+	if z.Lines[5].Until != nil {
+		t.Errorf("last line has until %+v", z.Lines[5].Until)
+	}
+	if d.Links[0] != (Link{"America/New_York", "US/Eastern"}) {
+		t.Errorf("link = %+v", d.Links[0])
+	}
+	if d.Compact() {
+		t.Error("source file reported as compact")
+	}
+}
+
+func TestParseCompact(t *testing.T) {
+	d := &Data{}
+	if err := d.Parse(strings.NewReader(compact), "tzdata.zi"); err != nil {
+		t.Fatal(err)
+	}
+	if d.Version != "2025b" || !d.Compact() {
+		t.Errorf("Version = %q, Compact = %v", d.Version, d.Compact())
+	}
+	if r := d.Rules["u"][1]; r.Month != time.November || r.To != MaxYear || r.IsDST {
+		t.Errorf("rule = %+v", r)
+	}
+	if r := d.Rules["E"][0]; r.At != (Time{3600, Universal}) {
+		t.Errorf("rule = %+v", r)
+	}
+	z, _ := d.Zone("Europe/Dublin")
+	if len(z.Lines) != 3 || z.Lines[1].Until.Time != (Time{7200, Standard}) || z.Lines[2].Rules != "E" {
+		t.Errorf("zone = %+v", z)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"Rule US 2007 max - Mar Sun>=8 2:00 1:00",
+		"Rule US 2007 max - Foo Sun>=8 2:00 1:00 D",
+		"Rule US 2007 2006 - Mar 8 2:00 1:00 D",
+		"Zone Foo -5:00 - EST 1920",
+		"Zone Foo -5:0x - EST",
+		"Link Foo",
+		"Frobnicate Foo",
+		"L Foo Bar", // ambiguous in the full form but Link in the compact form
+	} {
+		err := (&Data{}).Parse(strings.NewReader(input), "test")
+		if (err == nil) != strings.HasPrefix(input, "L ") {
+			t.Errorf("Parse(%q) error = %v", input, err)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	d := &Data{}
+	if err := d.Parse(strings.NewReader(northamerica), "northamerica"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Rules["US"][2].String(), "Rule\tUS\t2007\tmax\t-\tMar\tSun>=8\t2:00\t1:00\tD"; got != want {
+		t.Errorf("Rule.String() = %q, want %q", got, want)
+	}
+	if got, want := d.Zones[0].Lines[0].String(), "-4:56:02\t-\tLMT\t1883 Nov 18 17:00u"; got != want {
+		t.Errorf("ZoneLine.String() = %q, want %q", got, want)
+	}
+	if got, want := d.Zones[0].Lines[1].String(), "-5:00\tUS\tE%sT\t1920"; got != want {
+		t.Errorf("ZoneLine.String() = %q, want %q", got, want)
+	}
+	// The formatted lines parse back to the same records.
+	again := &Data{}
+	text := d.Rules["US"][2].String() + "\nZone America/New_York\t" + d.Zones[0].Lines[5].String() + "\n"
+	if err := again.Parse(strings.NewReader(text), "formatted"); err != nil {
+		t.Fatal(err)
+	}
+	if again.Rules["US"][0] != d.Rules["US"][2] || again.Zones[0].Lines[0].Format != "E%sT" {
+		t.Errorf("round trip = %+v", again)
+	}
+	zone := Zone{Name: "Test/Zone", Lines: d.Zones[0].Lines[:2]}
+	if got, want := zone.String(), "Zone\tTest/Zone\t-4:56:02\t-\tLMT\t1883 Nov 18 17:00u\n\t\t\t-5:00\tUS\tE%sT\t1920"; got != want {
+		t.Errorf("Zone.String() = %q, want %q", got, want)
+	}
+}
+
+func TestDayDate(t *testing.T) {
+	for _, test := range []struct {
+		day   string
+		month time.Month
+		want  int
+	}{
+		{"lastSun", time.March, 29},
+		{"Sun>=8", time.March, 8},
+		{"Sun>=1", time.November, 1},
+		{"Fri<=1", time.April, -4}, // March 27, 2026, as a day of April
+		{"15", time.June, 15},
+	} {
+		day, err := ParseDay(test.day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := day.Date(2026, test.month); got != test.want {
+			t.Errorf("%s in %s 2026 = %d, want %d", test.day, test.month, got, test.want)
+		}
+	}
+}
+
+func TestReadTzdataZi(t *testing.T) {
+	const path = "/usr/share/zoneinfo/tzdata.zi"
+	if _, err := os.Stat(path); err != nil {
+		t.Skip("no tzdata.zi on this system")
+	}
+	d, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.Zone("America/New_York"); !ok || len(d.Links) == 0 || d.Version == "" {
+		t.Errorf("version %q, %d zones, %d links", d.Version, len(d.Zones), len(d.Links))
+	}
+}
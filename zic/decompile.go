@@ -0,0 +1,438 @@
+package zic
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tzlist/posix/tzposix"
+	"github.com/tzlist/rfc9636"
+	"github.com/tzlist/tzsource"
+)
+
+// A period is a span of time with one local time type, starting at a transition.
+type period struct {
+	start  int64 // minTime for the first period
+	tt     rfc9636.TimeType
+	stdoff int64 // the standard offset the type belongs to
+}
+
+func (p period) save() int64 { return int64(p.tt.Offset) - p.stdoff }
+
+// A ruleKey holds the fields of a rule that are the same in every year.
+type ruleKey struct {
+	month   time.Month
+	at      tzsource.Time
+	save    int64
+	isDST   bool
+	letters string
+}
+
+// A ruleGroup collects the transitions of consecutive years that one Rule line can express.
+type ruleGroup struct {
+	ruleKey
+	from, to   int
+	day        int          // the day of month, -1 once it differs between years
+	weekday    time.Weekday // -1 once it differs between years
+	last       bool         // the day is always in the last week of the month
+	geqLo      int          // the days d for which "weekday>=d" fits every year
+	geqHi      int
+	firstLocal time.Time
+}
+
+// fit reports whether the group, extended by a transition on local date t, still has
+// a day form that fits every year.
+func (g *ruleGroup) fit(t time.Time) (ruleGroup, bool) {
+	n := *g
+	n.to = t.Year()
+	if n.day != t.Day() {
+		n.day = -1
+	}
+	if n.weekday != t.Weekday() {
+		n.weekday = -1
+	}
+	n.last = n.last && isLastWeek(t)
+	n.geqLo, n.geqHi = max(n.geqLo, t.Day()-6), min(n.geqHi, t.Day())
+	return n, n.day >= 0 || n.weekday >= 0 && (n.last || n.geqLo <= n.geqHi)
+}
+
+// on returns the ON field of the group's rule, preferring the forms the tz database uses.
+func (g *ruleGroup) on() tzsource.Day {
+	switch {
+	case g.day >= 0:
+		return tzsource.Day{Kind: tzsource.DayOfMonth, Day: g.day}
+	case g.last:
+		return tzsource.Day{Kind: tzsource.LastWeekday, Weekday: g.weekday}
+	}
+	day := g.geqLo
+	for _, d := range []int{1, 8, 15, 22} {
+		if g.geqLo <= d && d <= g.geqHi {
+			day = d
+		}
+	}
+	return tzsource.Day{Kind: tzsource.WeekdayOnOrAfter, Weekday: g.weekday, Day: day}
+}
+
+func isLastWeek(t time.Time) bool {
+	return t.AddDate(0, 0, 7).Month() != t.Month()
+}
+
+// A lineFormat splits the abbreviations of a zone line into a FORMAT and rule letters.
+type lineFormat struct {
+	format         string
+	prefix, suffix string // around %s, for letters
+	full           bool   // the letters are the whole abbreviation
+}
+
+func (f lineFormat) letters(abbr string) string {
+	if f.full {
+		return abbr
+	}
+	if f.prefix == "" && f.suffix == "" {
+		return ""
+	}
+	return abbr[len(f.prefix) : len(abbr)-len(f.suffix)]
+}
+
+// formatFor chooses the FORMAT of a zone line with rules: %z for numeric abbreviations,
+// a common prefix and suffix around %s as in "E%sT", "STD/DST", or else "%s" with the
+// whole abbreviations as letters.
+func formatFor(periods []period) lineFormat {
+	var abbrs []string
+	var stdAbbr, dstAbbr string
+	numeric, dsts := true, 0
+	for _, p := range periods {
+		numeric = numeric && p.tt.Name == abbroffset(int64(p.tt.Offset))
+		if !slices.Contains(abbrs, p.tt.Name) {
+			abbrs = append(abbrs, p.tt.Name)
+			if p.tt.IsDST {
+				dsts++
+				dstAbbr = p.tt.Name
+			} else {
+				stdAbbr = p.tt.Name
+			}
+		}
+	}
+	if numeric {
+		return lineFormat{format: "%z"}
+	}
+	prefix, suffix := abbrs[0], abbrs[0]
+	shortest := len(abbrs[0])
+	for _, abbr := range abbrs[1:] {
+		for !strings.HasPrefix(abbr, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+		for !strings.HasSuffix(abbr, suffix) {
+			suffix = suffix[1:]
+		}
+		shortest = min(shortest, len(abbr))
+	}
+	suffix = suffix[max(0, len(prefix)+len(suffix)-shortest):]
+	switch {
+	case prefix != "":
+		return lineFormat{format: prefix + "%s" + suffix, prefix: prefix, suffix: suffix}
+	case dsts == 1 && stdAbbr != "":
+		return lineFormat{format: stdAbbr + "/" + dstAbbr}
+	}
+	return lineFormat{format: "%s", full: true}
+}
+
+func abs(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// ruleName turns the last component of a zone name into a rule name.
+func ruleName(zone string) string {
+	base := []byte(zone[strings.LastIndexByte(zone, '/')+1:])
+	for i, c := range base {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			base[i] = '_'
+		}
+	}
+	if len(base) == 0 || !('a' <= base[0] && base[0] <= 'z' || 'A' <= base[0] && base[0] <= 'Z') {
+		base = append([]byte("Z"), base...)
+	}
+	return string(base)
+}
+
+// permanentDST reports whether a footer describes daylight saving time all year, as
+// zic writes it: "XXX3EDT4,0/0,J365/25".
+func permanentDST(tz tzposix.TZ) bool {
+	return tz.HasDST() && tz.Start.Kind == tzposix.RuleDayOfYear && tz.Start.Day == 0 &&
+		tz.Start.Time == 0 && tz.End.Kind == tzposix.RuleJulian && tz.End.Day == 365
+}
+
+// posixRule converts a footer rule to the ON field of a zic rule.
+func posixRule(r tzposix.Rule) (time.Month, tzsource.Day, error) {
+	if r.Kind != tzposix.RuleMonthWeekDay {
+		return 0, tzsource.Day{}, fmt.Errorf("footer rule of kind %v has no zic equivalent", r.Kind)
+	}
+	if r.Week == 5 {
+		return time.Month(r.Month), tzsource.Day{Kind: tzsource.LastWeekday, Weekday: time.Weekday(r.Day)}, nil
+	}
+	return time.Month(r.Month), tzsource.Day{Kind: tzsource.WeekdayOnOrAfter, Weekday: time.Weekday(r.Day),
+		Day: 1 + 7*(r.Week-1)}, nil
+}
+
+// untilAt returns the until fields for the wall clock time local, in seconds.
+func untilAt(local int64) *tzsource.Until {
+	t := time.Unix(local, 0).UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return &tzsource.Until{Year: t.Year(), Month: t.Month(), Day: tzsource.Day{Kind: tzsource.DayOfMonth, Day: t.Day()},
+		Time: tzsource.Time{Seconds: local - midnight.Unix()}}
+}
+
+// Decompile reconstructs zic source for loc from its transitions and footer. Zone lines
+// change where the standard offset or abbreviation does. The daylight saving changes
+// within a line become rules, grouped into one Rule line for each run of consecutive
+// years with the same change, and the footer becomes rules through "max". Compiling the
+// result gives the same local time types at every instant, though not the same file.
+func Decompile(loc *rfc9636.Location) (*tzsource.Data, error) {
+	name := loc.Name()
+	var footer tzposix.TZ
+	if loc.Extend() != "" {
+		var err error
+		if footer, err = tzposix.Parse(loc.Extend()); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	footerRules := footer.HasDST() && !permanentDST(footer)
+
+	// Collect the periods through the table, and two years of the footer beyond it.
+	from, horizon := int64(minTime), int64(maxTime)
+	lastYear := epochYear
+	if end, ok := loc.TableEnd(); ok {
+		lastYear = time.Unix(end, 0).UTC().Year()
+		horizon = end + 1
+	} else {
+		from = 0
+	}
+	if footerRules {
+		horizon = time.Date(lastYear+3, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	first, _, _ := loc.Lookup(from)
+	periods := []period{{start: minTime, tt: first}}
+	if horizon != maxTime {
+		for _, tx := range loc.Transitions(from, horizon) {
+			periods = append(periods, period{start: tx.When, tt: tx.After})
+		}
+	}
+
+	// Daylight saving time belongs to the standard time before or after it, whichever
+	// makes the smaller saving.
+	for i := range periods {
+		p := &periods[i]
+		p.stdoff = int64(p.tt.Offset)
+		if !p.tt.IsDST {
+			continue
+		}
+		prev, next := -1, -1
+		for j := i - 1; j >= 0 && prev < 0; j-- {
+			if !periods[j].tt.IsDST {
+				prev = j
+			}
+		}
+		for j := i + 1; j < len(periods) && next < 0; j++ {
+			if !periods[j].tt.IsDST {
+				next = j
+			}
+		}
+		// Standard time periods later on have no stdoff yet; it is their offset.
+		saving := func(j int) int64 {
+			if j < 0 || periods[j].tt.Offset == p.tt.Offset {
+				return math.MaxInt64
+			}
+			return abs(int64(p.tt.Offset - periods[j].tt.Offset))
+		}
+		switch {
+		case next < 0 && loc.Extend() != "":
+			p.stdoff = int64(footer.StdOffset)
+		case prev >= 0 && saving(prev) <= saving(next):
+			p.stdoff = int64(periods[prev].tt.Offset)
+		case next >= 0:
+			p.stdoff = int64(periods[next].tt.Offset)
+		default:
+			p.stdoff -= 60 * 60
+		}
+	}
+
+	// Split the periods into zone lines. The first period gets a line of its own, as
+	// zic takes the type before the first transition of a line with rules from its rules.
+	var lines [][]period
+	var stdAbbr string
+	for i, p := range periods {
+		n := len(lines)
+		if n <= 1 || p.stdoff != lines[n-1][0].stdoff || !p.tt.IsDST && stdAbbr != "" && p.tt.Name != stdAbbr {
+			lines = append(lines, nil)
+			n++
+			stdAbbr = ""
+		}
+		if !p.tt.IsDST {
+			stdAbbr = p.tt.Name
+		}
+		lines[n-1] = append(lines[n-1], periods[i])
+	}
+
+	data := &tzsource.Data{Rules: make(map[string][]tzsource.Rule)}
+	zone := tzsource.Zone{Name: name}
+	base, ruleSets := ruleName(name), 0
+	for i, ps := range lines {
+		zl := tzsource.ZoneLine{StdOff: ps[0].stdoff}
+		if i < len(lines)-1 {
+			zl.Until = untilAt(lines[i+1][0].start + int64(ps[len(ps)-1].tt.Offset))
+		}
+		last := i == len(lines)-1
+		switch {
+		case len(ps) == 1 && !ps[0].tt.IsDST && !(last && footerRules):
+			zl.Format = ps[0].tt.Name
+		case len(ps) == 1 && !(last && footerRules):
+			zl.Format, zl.Save, zl.HasSave = ps[0].tt.Name, ps[0].save(), true
+		default:
+			ruleSets++
+			zl.Rules = base
+			if ruleSets > 1 {
+				zl.Rules = fmt.Sprintf("%s_%d", base, ruleSets)
+			}
+			f := formatFor(ps)
+			zl.Format = f.format
+			rules, err := lineRules(zl.Rules, ps, i > 0, f, last && footerRules, footer)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			data.Rules[zl.Rules] = rules
+		}
+		zone.Lines = append(zone.Lines, zl)
+	}
+	data.Zones = append(data.Zones, zone)
+	return data, nil
+}
+
+// lineRules returns the rules of a zone line for the transitions between its periods.
+// A line that follows another gets a rule at its start when it starts in daylight saving
+// time or would otherwise have no rule to take its standard time letters from. The rules
+// of the footer, when given, continue the line's rules through "max".
+func lineRules(name string, ps []period, follows bool, f lineFormat, useFooter bool, footer tzposix.TZ) ([]tzsource.Rule, error) {
+	consumed := make(map[int64]bool)
+	var rules []tzsource.Rule
+	if useFooter {
+		when := make(map[int64]int)
+		for k, p := range ps[1:] {
+			when[p.start] = k + 1
+		}
+		fromYear := -1
+		for y := time.Unix(ps[len(ps)-1].start, 0).UTC().Year(); ; y-- {
+			start, end, _ := footer.Transitions(y)
+			ks, okStart := when[start]
+			ke, okEnd := when[end]
+			if !okStart || !okEnd || ps[ks].tt.Offset != footer.DstOffset || ps[ke].tt.Offset != footer.StdOffset {
+				break
+			}
+			consumed[start], consumed[end] = true, true
+			fromYear = y
+		}
+		if fromYear < 0 {
+			return nil, fmt.Errorf("the transitions do not follow the footer %q", footer.Raw)
+		}
+		for _, r := range []struct {
+			rule  tzposix.Rule
+			save  int64
+			isDST bool
+			abbr  string
+		}{{footer.Start, int64(footer.Saving()), true, footer.DstName}, {footer.End, 0, false, footer.StdName}} {
+			month, on, err := posixRule(r.rule)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, tzsource.Rule{Name: name, From: fromYear, To: tzsource.MaxYear, Month: month, On: on,
+				At: tzsource.Time{Seconds: int64(r.rule.Time)}, Save: r.save, IsDST: r.isDST, Letters: f.letters(r.abbr)})
+		}
+	}
+
+	// zic takes the abbreviation at the start from the first later rule without saving.
+	needStart := follows && (ps[0].save() != 0 || ps[0].tt.IsDST)
+	if follows && !needStart {
+		k := slices.IndexFunc(ps[1:], func(p period) bool { return p.save() == 0 })
+		needStart = k < 0 || ps[k+1].tt.Name != ps[0].tt.Name
+	}
+
+	var groups []*ruleGroup
+	open := make(map[ruleKey]*ruleGroup)
+	for k, p := range ps {
+		if k == 0 && !needStart || consumed[p.start] {
+			continue
+		}
+		// Rule times are in the wall clock time before the change, except for the start
+		// rule, which zic measures against standard time.
+		at := tzsource.Time{}
+		local := p.start
+		if k > 0 {
+			local += int64(ps[k-1].tt.Offset)
+		} else {
+			at.Kind = tzsource.Universal
+		}
+		t := time.Unix(local, 0).UTC()
+		at.Seconds = local - time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()
+		key := ruleKey{month: t.Month(), at: at, save: p.save(), isDST: p.tt.IsDST, letters: f.letters(p.tt.Name)}
+		if g, ok := open[key]; ok && g.to == t.Year()-1 {
+			if n, ok := g.fit(t); ok {
+				*g = n
+				continue
+			}
+		}
+		g := &ruleGroup{ruleKey: key, from: t.Year(), to: t.Year(), day: t.Day(), weekday: t.Weekday(),
+			last: isLastWeek(t), geqLo: max(1, t.Day()-6), geqHi: t.Day(), firstLocal: t}
+		groups = append(groups, g)
+		open[key] = g
+	}
+
+	var grouped []tzsource.Rule
+	for _, g := range groups {
+		on := tzsource.Day{Kind: tzsource.DayOfMonth, Day: g.firstLocal.Day()}
+		if g.from != g.to {
+			on = g.on()
+		}
+		grouped = append(grouped, tzsource.Rule{Name: name, From: g.from, To: g.to, Month: g.month, On: on,
+			At: g.at, Save: g.save, IsDST: g.isDST, Letters: g.letters})
+	}
+	grouped = append(grouped, rules...)
+	slices.SortStableFunc(grouped, func(a, b tzsource.Rule) int { return a.From - b.From })
+	return grouped, nil
+}
+
+// Verify compiles the zone of data named like loc and reports the first difference
+// between the two in the local time types until 2100.
+func Verify(loc *rfc9636.Location, data *tzsource.Data) error {
+	zone, ok := data.Zone(loc.Name())
+	if !ok {
+		return fmt.Errorf("%s: zone missing from the source", loc.Name())
+	}
+	tzif, err := CompileZone(data, zone, DefaultOptions)
+	if err != nil {
+		return err
+	}
+	got, err := rfc9636.LoadLocationFromTZData(loc.Name(), tzif)
+	if err != nil {
+		return err
+	}
+	g, _, _ := got.Lookup(minTime)
+	w, _, _ := loc.Lookup(minTime)
+	if g != w {
+		return fmt.Errorf("%s: starts with %+v, want %+v", loc.Name(), g, w)
+	}
+	to := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	gt, wt := got.Transitions(minTime, to), loc.Transitions(minTime, to)
+	for i := range min(len(gt), len(wt)) {
+		if gt[i] != wt[i] {
+			return fmt.Errorf("%s: transition %+v, want %+v", loc.Name(), gt[i], wt[i])
+		}
+	}
+	if len(gt) != len(wt) {
+		return fmt.Errorf("%s: %d transitions, want %d", loc.Name(), len(gt), len(wt))
+	}
+	return nil
+}
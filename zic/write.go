@@ -0,0 +1,515 @@
+package zic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ruleCmp orders rules by the last year they apply, then by the date they apply on.
+func ruleCmp(a, b *zrule) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.hiyear != b.hiyear:
+		if a.hiyear < b.hiyear {
+			return -1
+		}
+		return 1
+	case a.month != b.month:
+		return a.month - b.month
+	}
+	return a.dayofmonth - b.dayofmonth
+}
+
+// stringzone returns the POSIX TZ string for the times after the last transition of the
+// zone, and the year of the oldest readers that handle it; -1 when there is no such string.
+func (c *compiler) stringzone(zones []zzone) (string, int) {
+	compat := 0
+	// RFC 8536 section 5.1 says to use an empty TZ string if future timestamps are truncated.
+	if c.opts.Hi < maxTime {
+		return "", -1
+	}
+	zp := &zones[len(zones)-1]
+	var stdrp, dstrp *zrule
+	for i := range zp.rules {
+		rp := &zp.rules[i]
+		if rp.hiwasnum || rp.hiyear != zicMax {
+			continue
+		}
+		if !rp.isdst {
+			if stdrp != nil {
+				return "", -1
+			}
+			stdrp = rp
+		} else {
+			if dstrp != nil {
+				return "", -1
+			}
+			dstrp = rp
+		}
+	}
+	if stdrp == nil && dstrp == nil {
+		// There are no rules running through "max". Find the latest std rule in
+		// stdabbrrp and the latest rule of any type in stdrp.
+		var stdabbrrp *zrule
+		for i := range zp.rules {
+			rp := &zp.rules[i]
+			if !rp.isdst && ruleCmp(stdabbrrp, rp) < 0 {
+				stdabbrrp = rp
+			}
+			if ruleCmp(stdrp, rp) < 0 {
+				stdrp = rp
+			}
+		}
+		if stdrp != nil && stdrp.isdst {
+			// Perpetual DST.
+			dstr := &zrule{month: 0, dycode: dcDOM, dayofmonth: 1, isdst: true,
+				save: stdrp.save, abbrvar: stdrp.abbrvar, hasAbbrvar: true}
+			stdr := &zrule{month: 11, dycode: dcDOM, dayofmonth: 31, tod: secsPerDay + stdrp.save, hasAbbrvar: true}
+			if stdabbrrp != nil {
+				stdr.abbrvar = stdabbrrp.abbrvar
+			}
+			dstrp, stdrp = dstr, stdr
+		}
+	}
+	if stdrp == nil && (len(zp.rules) != 0 || zp.isdst) {
+		return "", -1
+	}
+	var result strings.Builder
+	abbrvar := ""
+	if stdrp != nil {
+		abbrvar = stdrp.abbrvar
+	}
+	result.WriteString(doabbr(zp, &abbrvar, false, 0, true))
+	offset, ok := stringoffset(-zp.stdoff)
+	if !ok {
+		return "", -1
+	}
+	result.WriteString(offset)
+	if dstrp == nil {
+		return result.String(), compat
+	}
+	result.WriteString(doabbr(zp, letterPtr(dstrp), dstrp.isdst, dstrp.save, true))
+	if dstrp.save != 3600 {
+		offset, ok := stringoffset(-(zp.stdoff + dstrp.save))
+		if !ok {
+			return "", -1
+		}
+		result.WriteString(offset)
+	}
+	for _, rp := range []*zrule{dstrp, stdrp} {
+		rule, rc := stringrule(rp, dstrp.save, zp.stdoff)
+		if rc < 0 {
+			return "", -1
+		}
+		compat = max(compat, rc)
+		result.WriteString("," + rule)
+	}
+	return result.String(), compat
+}
+
+// stringoffset formats an offset for a POSIX TZ string, such as "5", "-3:30" or "0:25:21".
+func stringoffset(offset int64) (string, bool) {
+	sign := ""
+	if offset < 0 {
+		offset, sign = -offset, "-"
+	}
+	seconds, minutes, hours := offset%60, offset/60%60, offset/3600
+	if hours >= 24*7 {
+		return "", false
+	}
+	s := fmt.Sprintf("%s%d", sign, hours)
+	if minutes != 0 || seconds != 0 {
+		s += fmt.Sprintf(":%02d", minutes)
+		if seconds != 0 {
+			s += fmt.Sprintf(":%02d", seconds)
+		}
+	}
+	return s, true
+}
+
+// stringrule formats the date and time of a rule for a POSIX TZ string.
+func stringrule(rp *zrule, save, stdoff int64) (string, int) {
+	tod := rp.tod
+	compat := 0
+	var s string
+	if rp.dycode == dcDOM {
+		if rp.dayofmonth == 29 && rp.month == 1 {
+			return "", -1
+		}
+		total := 0
+		for month := 0; month < rp.month; month++ {
+			total += lenMonths[0][month]
+		}
+		// Omit the "J" in Jan and Feb, as that's shorter.
+		if rp.month <= 1 {
+			s = fmt.Sprint(total + rp.dayofmonth - 1)
+		} else {
+			s = fmt.Sprintf("J%d", total+rp.dayofmonth)
+		}
+	} else {
+		var week int
+		wday := rp.wday
+		if rp.dycode == dcDOWGEQ {
+			wdayoff := (rp.dayofmonth - 1) % 7
+			if wdayoff != 0 {
+				compat = 2013
+			}
+			wday -= wdayoff
+			tod += int64(wdayoff) * secsPerDay
+			week = 1 + (rp.dayofmonth-1)/7
+		} else if rp.dayofmonth == lenMonths[1][rp.month] {
+			week = 5
+		} else {
+			wdayoff := rp.dayofmonth % 7
+			if wdayoff != 0 {
+				compat = 2013
+			}
+			wday -= wdayoff
+			tod += int64(wdayoff) * secsPerDay
+			week = rp.dayofmonth / 7
+		}
+		if wday < 0 {
+			wday += 7
+		}
+		s = fmt.Sprintf("M%d.%d.%d", rp.month+1, week, wday)
+	}
+	if rp.todisut {
+		tod += stdoff
+	}
+	if rp.todisstd && !rp.isdst {
+		tod += save
+	}
+	if tod != 2*3600 {
+		offset, ok := stringoffset(tod)
+		if !ok {
+			return "", -1
+		}
+		s += "/" + offset
+		if tod < 0 {
+			compat = max(compat, 2013)
+		} else if tod >= secsPerDay {
+			compat = max(compat, 1994)
+		}
+	}
+	return s, compat
+}
+
+// timerange is the part of the transitions written for one of the 32-bit and 64-bit passes.
+type timerange struct {
+	defaulttype int
+	base, count int
+}
+
+// limitrange omits the transitions outside [lo, hi+1].
+func limitrange(r timerange, lo, hi int64, ats []int64, types []int) timerange {
+	for r.count > 0 && ats[r.base] < lo {
+		r.defaulttype = types[r.base]
+		r.count--
+		r.base++
+	}
+	if hi < maxTime {
+		for r.count > 0 && hi+1 < ats[r.base+r.count-1] {
+			r.count--
+		}
+	}
+	return r
+}
+
+// writezone optimizes the transitions and writes them as TZif with the footer.
+func (c *compiler) writezone(footer string, version byte, defaulttype int) ([]byte, error) {
+	lo, hi := c.opts.Lo, c.opts.Hi
+
+	slices.SortStableFunc(c.attypes, func(a, b attype) int {
+		switch {
+		case a.at < b.at:
+			return -1
+		case a.at > b.at:
+			return 1
+		}
+		return 0
+	})
+
+	// Optimize.
+	toi := 0
+	for fromi := range c.attypes {
+		if toi != 0 {
+			prev := 0
+			if toi > 1 {
+				prev = c.attypes[toi-2].typ
+			}
+			if c.attypes[fromi].at+c.utoffs[c.attypes[toi-1].typ] <= c.attypes[toi-1].at+c.utoffs[prev] {
+				c.attypes[toi-1].typ = c.attypes[fromi].typ
+				continue
+			}
+		}
+		if toi == 0 || c.attypes[fromi].dontmerge ||
+			c.utoffs[c.attypes[toi-1].typ] != c.utoffs[c.attypes[fromi].typ] ||
+			c.isdsts[c.attypes[toi-1].typ] != c.isdsts[c.attypes[fromi].typ] ||
+			c.desigidx[c.attypes[toi-1].typ] != c.desigidx[c.attypes[fromi].typ] {
+			c.attypes[toi] = c.attypes[fromi]
+			toi++
+		}
+	}
+	c.attypes = c.attypes[:toi]
+
+	ats := make([]int64, len(c.attypes), len(c.attypes)+1)
+	types := make([]int, len(c.attypes), len(c.attypes)+1)
+	for i, at := range c.attypes {
+		ats[i], types[i] = at.at, at.typ
+	}
+
+	// Work around QTBUG-53071 for timestamps less than y2038Boundary - 1 by inserting
+	// a no-op transition at time y2038Boundary - 1.
+	if n := len(ats); n != 0 && c.wantBloat() && ats[n-1] < y2038Boundary-1 && strings.Contains(footer, "<") {
+		ats = append(ats, y2038Boundary-1)
+		types = append(types, types[n-1])
+	}
+
+	rangeall := timerange{defaulttype: defaulttype, count: len(ats)}
+	range64 := limitrange(rangeall, lo, hi, ats, types)
+	range32 := limitrange(range64, math.MinInt32, math.MaxInt32, ats, types)
+
+	var out bytes.Buffer
+	for pass := 1; pass <= 2; pass++ {
+		var r timerange
+		var thismin, thismax int64
+		if pass == 1 {
+			r, thismin, thismax = range32, math.MinInt32, math.MaxInt32
+		} else {
+			r, thismin, thismax = range64, minTime, maxTime
+		}
+		thisdefaulttype := r.defaulttype
+		thistimei, thistimecnt := r.base, r.count
+		pretranstype := -1
+		locut := thismin < lo
+		hicut := hi < thismax
+		thistimelim := thistimei + thistimecnt
+		omittype := make([]bool, len(c.utoffs), 256)
+		for i := range omittype {
+			omittype[i] = true
+		}
+
+		// Determine whether to output a transition before the first transition in range.
+		// This is needed when the output is truncated at the start, and is also useful
+		// when catering to buggy 32-bit clients that do not use time type 0 for
+		// timestamps before the first transition.
+		if (locut || (pass == 1 && thistimei != 0)) && !(thistimecnt != 0 && ats[thistimei] == lo) {
+			pretranstype = thisdefaulttype
+			omittype[pretranstype] = false
+		}
+		// The 32-bit data traditionally uses the type of the indefinite past.
+		if pass == 1 && lo <= thismin {
+			thisdefaulttype = range64.defaulttype
+		}
+		omittype[thisdefaulttype] = false
+		for i := thistimei; i < thistimelim; i++ {
+			omittype[types[i]] = false
+		}
+
+		// Reorder types to make thisdefaulttype type 0, swapping it with old0.
+		old0 := slices.Index(omittype, false)
+		swap := func(i int) int {
+			switch i {
+			case old0:
+				return thisdefaulttype
+			case thisdefaulttype:
+				return old0
+			}
+			return i
+		}
+
+		if c.wantBloat() {
+			// For some pre-2011 systems: if the last-to-be-written standard (or daylight)
+			// type has an offset different from the most recently used offset, append an
+			// unused copy of the most recently used type to help set the global
+			// "altzone" and "timezone" variables correctly.
+			hidst, histd, mrudst, mrustd := -1, -1, -1, -1
+			if pretranstype >= 0 {
+				if c.isdsts[pretranstype] != 0 {
+					mrudst = pretranstype
+				} else {
+					mrustd = pretranstype
+				}
+			}
+			for i := thistimei; i < thistimelim; i++ {
+				if c.isdsts[types[i]] != 0 {
+					mrudst = types[i]
+				} else {
+					mrustd = types[i]
+				}
+			}
+			for i := old0; i < len(c.utoffs); i++ {
+				if h := swap(i); !omittype[h] {
+					if c.isdsts[h] != 0 {
+						hidst = i
+					} else {
+						histd = i
+					}
+				}
+			}
+			for _, mru := range []struct {
+				hi, mru int
+				isdst   bool
+			}{{hidst, mrudst, true}, {histd, mrustd, false}} {
+				if mru.hi >= 0 && mru.mru >= 0 && mru.hi != mru.mru && c.utoffs[mru.hi] != c.utoffs[mru.mru] {
+					saved := c.isdsts[mru.mru]
+					c.isdsts[mru.mru] = -1
+					typ, err := c.addtype(c.utoffs[mru.mru], cstring(c.chars[c.desigidx[mru.mru]:]),
+						mru.isdst, c.ttisstds[mru.mru], c.ttisuts[mru.mru])
+					c.isdsts[mru.mru] = saved
+					if err != nil {
+						return nil, err
+					}
+					// The copy made in the 32-bit pass is found again in the 64-bit one.
+					for len(omittype) < len(c.utoffs) {
+						omittype = append(omittype, true)
+					}
+					omittype[typ] = false
+				}
+			}
+		}
+
+		typemap := make([]int, len(c.utoffs))
+		thistypecnt := 0
+		for i := old0; i < len(c.utoffs); i++ {
+			if !omittype[i] {
+				typemap[swap(i)] = thistypecnt
+				thistypecnt++
+			}
+		}
+
+		indmap := make(map[int]int)
+		var thischars []byte
+		stdcnt, utcnt := 0, 0
+		for i := old0; i < len(c.utoffs); i++ {
+			if omittype[i] {
+				continue
+			}
+			if c.ttisstds[i] {
+				stdcnt = thistypecnt
+			}
+			if c.ttisuts[i] {
+				utcnt = thistypecnt
+			}
+			if _, exists := indmap[c.desigidx[i]]; exists {
+				continue
+			}
+			thisabbr := cstring(c.chars[c.desigidx[i]:])
+			j := 0
+			for ; j < len(thischars); j++ {
+				if cstring(thischars[j:]) == thisabbr {
+					break
+				}
+			}
+			if j == len(thischars) {
+				thischars = append(append(thischars, thisabbr...), 0)
+			}
+			indmap[c.desigidx[i]] = j
+		}
+		thischarcnt := len(thischars)
+		if pass == 1 && !c.wantBloat() {
+			hicut = false
+			pretranstype = -1
+			thistimecnt = 0
+			thistypecnt, thischarcnt = 1, 1
+		}
+
+		pretrans, hicount := 0, 0
+		if pretranstype >= 0 {
+			pretrans = 1
+		}
+		if hicut {
+			hicount = 1
+		}
+		out.WriteString("TZif")
+		out.WriteByte(version)
+		out.Write(make([]byte, 15))
+		for _, count := range []int{utcnt, stdcnt, 0, pretrans + thistimecnt + hicount, thistypecnt, thischarcnt} {
+			putcode(&out, int64(count), 1)
+		}
+		if pass == 1 && !c.wantBloat() {
+			// Output a minimal data block with just one time type.
+			putcode(&out, 0, 1) // utoff
+			out.WriteByte(0)    // dst
+			out.WriteByte(0)    // index of abbreviation
+			out.WriteByte(0)    // empty-string abbreviation
+			continue
+		}
+
+		// Output a lo transition if needed, but not below the minimum of this pass.
+		lopass := lo
+		if pass == 1 && lo < math.MinInt32 {
+			lopass = math.MinInt32
+		}
+		if pretranstype >= 0 {
+			putcode(&out, lopass, pass)
+		}
+		for i := thistimei; i < thistimelim; i++ {
+			putcode(&out, max(ats[i], lopass), pass)
+		}
+		if hicut {
+			putcode(&out, hi+1, pass)
+		}
+		// The transition at hi+1 keeps the type in effect; only the footer is dropped.
+		currenttype := 0
+		if pretranstype >= 0 {
+			currenttype = typemap[pretranstype]
+			out.WriteByte(byte(currenttype))
+		}
+		for i := thistimei; i < thistimelim; i++ {
+			currenttype = typemap[types[i]]
+			out.WriteByte(byte(currenttype))
+		}
+		if hicut {
+			out.WriteByte(byte(currenttype))
+		}
+		for i := old0; i < len(c.utoffs); i++ {
+			if h := swap(i); !omittype[h] {
+				putcode(&out, c.utoffs[h], 1)
+				out.WriteByte(byte(c.isdsts[h]))
+				out.WriteByte(byte(indmap[c.desigidx[h]]))
+			}
+		}
+		out.Write(thischars)
+		for _, indicators := range []struct {
+			count int
+			flags []bool
+		}{{stdcnt, c.ttisstds}, {utcnt, c.ttisuts}} {
+			if indicators.count == 0 {
+				continue
+			}
+			for i := old0; i < len(c.utoffs); i++ {
+				if !omittype[i] {
+					out.WriteByte(boolByte(indicators.flags[i]))
+				}
+			}
+		}
+	}
+	fmt.Fprintf(&out, "\n%s\n", footer)
+	return out.Bytes(), nil
+}
+
+// putcode writes a 32-bit value in pass 1 and a 64-bit value in pass 2, big endian.
+func putcode(out *bytes.Buffer, v int64, pass int) {
+	if pass == 1 {
+		out.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(v))))
+	} else {
+		out.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+	}
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,689 @@
+// Package zic compiles tz database source, as parsed by package tzsource, into TZif files.
+// It follows the reference zic closely enough that its output for the official sources is
+// byte for byte what zic writes with the same -b and -r options, except where slim output
+// of zic 2022 and earlier disagrees with its own fat output. Leap seconds (-L) are not
+// supported.
+package zic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tzlist/tzsource"
+)
+
+const (
+	minTime = math.MinInt64
+	maxTime = math.MaxInt64
+
+	zicMin = math.MinInt64 // the year "minimum"
+	zicMax = math.MaxInt64 // the year "maximum"
+
+	secsPerDay     = 24 * 60 * 60
+	daysPerNYear   = 365
+	yearsPerRepeat = 400
+	epochYear      = 1970
+
+	// Ignore transitions past 2038 that the footer can generate, unless asked for.
+	y2038Boundary = int64(1) << 31
+)
+
+// Options select the form of the output, like the -b and -r options of zic.
+type Options struct {
+	// Fat writes the redundant data that older readers need, like zic -b fat.
+	Fat bool
+	// Lo and Hi limit the output to the timestamps in [Lo, Hi], like zic -r @lo/@hi+1.
+	// A transition at Lo starts the data and, without a footer, one at Hi+1 ends it.
+	// Use NoLo and NoHi to leave a bound open.
+	Lo, Hi int64
+}
+
+// NoLo and NoHi leave the bounds of Options open.
+const (
+	NoLo = minTime
+	NoHi = maxTime
+)
+
+// DefaultOptions are the options zic uses without -b and -r.
+var DefaultOptions = Options{Lo: NoLo, Hi: NoHi}
+
+// ParseRange parses a zic -r argument, "[@lo][/@hi]", into options.
+// As with zic, hi itself is excluded from the range.
+func ParseRange(opts *Options, s string) error {
+	lo, hi := int64(minTime), int64(maxTime)
+	rest := s
+	if strings.HasPrefix(rest, "@") {
+		end := strings.IndexByte(rest, '/')
+		if end < 0 {
+			end = len(rest)
+		}
+		if _, err := fmt.Sscan(rest[1:end], &lo); err != nil {
+			return fmt.Errorf("invalid time range %q", s)
+		}
+		rest = rest[end:]
+	}
+	if strings.HasPrefix(rest, "/@") {
+		if _, err := fmt.Sscan(rest[2:], &hi); err != nil || hi == minTime {
+			return fmt.Errorf("invalid time range %q", s)
+		}
+		hi--
+		rest = ""
+	}
+	if rest != "" || hi < lo {
+		return fmt.Errorf("invalid time range %q", s)
+	}
+	opts.Lo, opts.Hi = lo, hi
+	return nil
+}
+
+// Day codes of a rule, as in zic.
+const (
+	dcDOM    = iota // 1
+	dcDOWGEQ        // Sun>=8
+	dcDOWLEQ        // Sun<=25 and lastSun
+)
+
+// zrule is a rule, or the until time of a zone line, in the form zic computes with.
+type zrule struct {
+	loyear, hiyear     int64
+	lowasnum, hiwasnum bool
+	month              int // 0 for January
+	dycode             int
+	dayofmonth         int
+	wday               int
+	tod                int64
+	todisstd, todisut  bool
+	isdst              bool
+	save               int64
+	abbrvar            string
+	hasAbbrvar         bool
+
+	todo bool
+	temp int64
+}
+
+var lenMonths = [2][12]int{
+	{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31},
+	{31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31},
+}
+
+func isLeap(y int64) bool {
+	return y%4 == 0 && (y%100 != 0 || y%400 == 0)
+}
+
+func year(y int) int64 {
+	switch y {
+	case tzsource.MinYear:
+		return zicMin
+	case tzsource.MaxYear:
+		return zicMax
+	}
+	return int64(y)
+}
+
+func setDay(r *zrule, month time.Month, d tzsource.Day) {
+	r.month = int(month) - 1
+	r.wday = int(d.Weekday)
+	r.dayofmonth = d.Day
+	switch d.Kind {
+	case tzsource.DayOfMonth:
+		r.dycode = dcDOM
+	case tzsource.LastWeekday:
+		r.dycode = dcDOWLEQ
+		r.dayofmonth = lenMonths[1][r.month]
+	case tzsource.WeekdayOnOrAfter:
+		r.dycode = dcDOWGEQ
+	case tzsource.WeekdayOnOrBefore:
+		r.dycode = dcDOWLEQ
+	}
+}
+
+func setTime(r *zrule, t tzsource.Time) {
+	r.tod = t.Seconds
+	// A universal time is also a standard time.
+	r.todisstd = t.Kind != tzsource.WallClock
+	r.todisut = t.Kind == tzsource.Universal
+}
+
+func newRule(r tzsource.Rule) zrule {
+	zr := zrule{
+		loyear: year(r.From), hiyear: year(r.To),
+		lowasnum: r.From != tzsource.MinYear, hiwasnum: r.To != tzsource.MaxYear && r.To != r.From,
+		isdst: r.IsDST, save: r.Save, abbrvar: r.Letters, hasAbbrvar: true,
+	}
+	setDay(&zr, r.Month, r.On)
+	setTime(&zr, r.At)
+	return zr
+}
+
+func newUntil(u *tzsource.Until) zrule {
+	zr := zrule{loyear: int64(u.Year), hiyear: int64(u.Year), lowasnum: true}
+	setDay(&zr, u.Month, u.Day)
+	setTime(&zr, u.Time)
+	return zr
+}
+
+// zzone is a zone line in the form zic computes with.
+type zzone struct {
+	name      string
+	stdoff    int64
+	format    string
+	specifier byte // 's' for %s, 'z' for %z, 0 for none
+	isdst     bool
+	save      int64
+	rules     []zrule
+	untilrule zrule
+	untiltime int64
+}
+
+func newZone(name string, zl tzsource.ZoneLine, rules map[string][]tzsource.Rule) (zzone, error) {
+	zz := zzone{name: name, stdoff: zl.StdOff, format: zl.Format}
+	if strings.Contains(zz.format, "%z") {
+		zz.specifier = 'z'
+		zz.format = strings.Replace(zz.format, "%z", "%s", 1)
+	} else if strings.Contains(zz.format, "%s") {
+		zz.specifier = 's'
+	}
+	if strings.Contains(zz.format, "/") && zz.specifier != 0 {
+		return zz, fmt.Errorf("zone %s: format %q may not contain both / and %%", name, zl.Format)
+	}
+	switch {
+	case zl.Rules != "":
+		source, exists := rules[zl.Rules]
+		if !exists {
+			return zz, fmt.Errorf("zone %s: unknown rule %q", name, zl.Rules)
+		}
+		for _, r := range source {
+			zz.rules = append(zz.rules, newRule(r))
+		}
+	case zl.HasSave:
+		zz.save, zz.isdst = zl.Save, zl.Save != 0
+	}
+	if zl.Until != nil {
+		zz.untilrule = newUntil(zl.Until)
+		zz.untiltime = rpytime(&zz.untilrule, zz.untilrule.loyear)
+	}
+	return zz, nil
+}
+
+// rpytime returns the time of the rule in the given year, in seconds since the epoch
+// on the clock the rule is given in.
+func rpytime(r *zrule, wantedy int64) int64 {
+	if wantedy == zicMin {
+		return minTime
+	}
+	if wantedy == zicMax {
+		return maxTime
+	}
+	i := r.dayofmonth
+	if r.month == 1 && i == 29 && !isLeap(wantedy) && r.dycode == dcDOWLEQ {
+		i--
+	}
+	i--
+	dayoff := time.Date(int(wantedy), time.Month(r.month+1), 1, 0, 0, 0, 0, time.UTC).Unix()/secsPerDay + int64(i)
+	if r.dycode == dcDOWGEQ || r.dycode == dcDOWLEQ {
+		wday := ((4+dayoff%7)%7 + 7) % 7 // the epoch is a Thursday
+		for wday != int64(r.wday) {
+			if r.dycode == dcDOWGEQ {
+				dayoff++
+				wday = (wday + 1) % 7
+			} else {
+				dayoff--
+				wday = (wday + 6) % 7
+			}
+		}
+	}
+	if dayoff < minTime/secsPerDay {
+		return minTime
+	}
+	if dayoff > maxTime/secsPerDay {
+		return maxTime
+	}
+	return tadd(dayoff*secsPerDay, r.tod)
+}
+
+// tadd adds without overflowing past minTime and maxTime.
+func tadd(t, delta int64) int64 {
+	if t == minTime || t == maxTime {
+		return t
+	}
+	switch {
+	case delta > 0 && t > maxTime-delta:
+		return maxTime
+	case delta < 0 && t < minTime-delta:
+		return minTime
+	}
+	return t + delta
+}
+
+// attype is a transition at a time to a local time type.
+type attype struct {
+	at        int64
+	dontmerge bool
+	typ       int
+}
+
+// compiler holds the state of one zone being compiled.
+type compiler struct {
+	opts Options
+
+	attypes  []attype
+	utoffs   []int64
+	isdsts   []int8
+	desigidx []int
+	ttisstds []bool
+	ttisuts  []bool
+	chars    []byte
+}
+
+func (c *compiler) wantBloat() bool { return c.opts.Fat }
+
+// addtype returns the index of the local time type, adding it if it is new.
+func (c *compiler) addtype(utoff int64, abbr string, isdst bool, ttisstd, ttisut bool) (int, error) {
+	if utoff < math.MinInt32 || utoff > math.MaxInt32 {
+		return 0, errors.New("UT offset out of range")
+	}
+	if !c.wantBloat() {
+		ttisstd, ttisut = false, false
+	}
+	dst := int8(0)
+	if isdst {
+		dst = 1
+	}
+	j := 0
+	for ; j < len(c.chars); j++ {
+		if cstring(c.chars[j:]) == abbr {
+			break
+		}
+	}
+	if j == len(c.chars) {
+		c.chars = append(append(c.chars, abbr...), 0)
+	} else {
+		for i := range c.utoffs {
+			if utoff == c.utoffs[i] && dst == c.isdsts[i] && j == c.desigidx[i] &&
+				ttisstd == c.ttisstds[i] && ttisut == c.ttisuts[i] {
+				return i, nil
+			}
+		}
+	}
+	if len(c.utoffs) >= 256 {
+		return 0, errors.New("too many local time types")
+	}
+	c.utoffs = append(c.utoffs, utoff)
+	c.isdsts = append(c.isdsts, dst)
+	c.ttisstds = append(c.ttisstds, ttisstd)
+	c.ttisuts = append(c.ttisuts, ttisut)
+	c.desigidx = append(c.desigidx, j)
+	return len(c.utoffs) - 1, nil
+}
+
+func (c *compiler) addtt(at int64, typ int) {
+	c.attypes = append(c.attypes, attype{at: at, typ: typ})
+}
+
+// cstring returns the NUL terminated string at the start of b.
+func cstring(b []byte) string {
+	if i := slices.Index(b, 0); i >= 0 {
+		return string(b[:i])
+	}
+	return string(b)
+}
+
+// disablePercentS asks doabbr for no abbreviation rather than a literal %s.
+const disablePercentS = "\x00"
+
+// doabbr formats the abbreviation of the zone for the rule letters. With quotes it is
+// written in the angle brackets a POSIX TZ string needs unless it is all letters.
+func doabbr(zp *zzone, letters *string, isdst bool, save int64, quotes bool) string {
+	var abbr string
+	if slash := strings.IndexByte(zp.format, '/'); slash < 0 {
+		l := "%s"
+		switch {
+		case zp.specifier == 'z':
+			l = abbroffset(zp.stdoff + save)
+		case letters == nil:
+		case *letters == disablePercentS:
+			return ""
+		default:
+			l = *letters
+		}
+		abbr = strings.Replace(zp.format, "%s", l, 1)
+	} else if isdst {
+		abbr = zp.format[slash+1:]
+	} else {
+		abbr = zp.format[:slash]
+	}
+	if !quotes {
+		return abbr
+	}
+	if abbr != "" && strings.Trim(abbr, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") == "" {
+		return abbr
+	}
+	return "<" + abbr + ">"
+}
+
+// abbroffset formats an offset for %z, such as "+05", "-0330" or "+053728".
+func abbroffset(offset int64) string {
+	sign := "+"
+	if offset < 0 {
+		offset, sign = -offset, "-"
+	}
+	seconds, minutes, hours := offset%60, offset/60%60, offset/3600
+	switch {
+	case seconds != 0:
+		return fmt.Sprintf("%s%02d%02d%02d", sign, hours, minutes, seconds)
+	case minutes != 0:
+		return fmt.Sprintf("%s%02d%02d", sign, hours, minutes)
+	}
+	return fmt.Sprintf("%s%02d", sign, hours)
+}
+
+func letterPtr(r *zrule) *string {
+	if r == nil || !r.hasAbbrvar {
+		return nil
+	}
+	return &r.abbrvar
+}
+
+// CompileZone compiles a zone of data into a TZif file.
+func CompileZone(data *tzsource.Data, zone tzsource.Zone, opts Options) ([]byte, error) {
+	zones := make([]zzone, 0, len(zone.Lines))
+	for _, zl := range zone.Lines {
+		zz, err := newZone(zone.Name, zl, data.Rules)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zz)
+	}
+	for i := 1; i < len(zones)-1; i++ {
+		if zones[i].untiltime <= zones[i-1].untiltime {
+			return nil, fmt.Errorf("zone %s: continuation line end time is not after end time of previous line", zone.Name)
+		}
+	}
+	c := &compiler{opts: opts}
+	return c.outzone(zones)
+}
+
+// Compile compiles every zone and link of data. Links get the data of their target.
+func Compile(data *tzsource.Data, opts Options) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(data.Zones)+len(data.Links))
+	for _, zone := range data.Zones {
+		tzif, err := CompileZone(data, zone, opts)
+		if err != nil {
+			return nil, err
+		}
+		files[zone.Name] = tzif
+	}
+	// A link may lead to another link; resolve them until nothing changes.
+	for pending := data.Links; len(pending) > 0; {
+		var next []tzsource.Link
+		for _, link := range pending {
+			if tzif, exists := files[link.Target]; exists {
+				files[link.Name] = tzif
+			} else {
+				next = append(next, link)
+			}
+		}
+		if len(next) == len(pending) {
+			return nil, fmt.Errorf("link %s to unknown zone %s", next[0].Name, next[0].Target)
+		}
+		pending = next
+	}
+	return files, nil
+}
+
+func (c *compiler) outzone(zones []zzone) ([]byte, error) {
+	var starttime, untiltime int64
+	var startttisstd, startttisut bool
+	defaulttype := -1
+	lastatmax := -1
+
+	prodstic := len(zones) == 1
+	minYear, maxYear := int64(epochYear), int64(epochYear)
+	updateminmax := func(y int64) {
+		minYear, maxYear = min(minYear, y), max(maxYear, y)
+	}
+	for i := range zones {
+		zp := &zones[i]
+		if i < len(zones)-1 {
+			updateminmax(zp.untilrule.loyear)
+		}
+		for j := range zp.rules {
+			rp := &zp.rules[j]
+			if rp.lowasnum {
+				updateminmax(rp.loyear)
+			}
+			if rp.hiwasnum {
+				updateminmax(rp.hiyear)
+			}
+			if rp.lowasnum || rp.hiwasnum {
+				prodstic = false
+			}
+		}
+	}
+
+	// Generate lots of data if a rule can't cover all future times.
+	envvar, compat := c.stringzone(zones)
+	version := byte('2')
+	if compat >= 2013 {
+		version = '3'
+	}
+	doExtend := compat < 0
+	if doExtend {
+		const yearsOfObservations = yearsPerRepeat + 2
+		minYear -= yearsOfObservations
+		maxYear += yearsOfObservations
+		if prodstic {
+			minYear = 1900
+			maxYear = minYear + yearsOfObservations
+		}
+	}
+	maxYear = max(maxYear, minTime/(secsPerDay*daysPerNYear)+epochYear+1)
+	maxYear0 := maxYear
+	if c.wantBloat() {
+		// For the benefit of older systems, generate data from 1900 through 2038.
+		minYear = min(minYear, 1900)
+		maxYear = max(maxYear, 2038)
+	}
+
+	for i := range zones {
+		zp := &zones[i]
+		var prevrp *zrule
+		var save int64
+		usestart := i > 0 && zones[i-1].untiltime > minTime
+		useuntil := i < len(zones)-1
+		stdoff := zp.stdoff
+		startoff := stdoff
+		startbuf := ""
+
+		if useuntil && zp.untiltime <= minTime {
+			continue
+		}
+		lastFiniteYear := int64(zicMin)
+		for _, r := range zp.rules {
+			if r.hiyear != zicMax {
+				lastFiniteYear = max(lastFiniteYear, r.hiyear)
+			}
+		}
+		// Slim output of the last line continues until the footer can take over; zic
+		// 2022 and earlier stop at maxYear, leaving a table whose last transition
+		// disagrees with the footer when the line starts late in that year.
+		extend := !c.wantBloat() && !useuntil && !doExtend &&
+			slices.ContainsFunc(zp.rules, func(r zrule) bool { return r.hiyear == zicMax })
+		if len(zp.rules) == 0 {
+			save = zp.save
+			startbuf = doabbr(zp, nil, zp.isdst, save, false)
+			typ, err := c.addtype(zp.stdoff+save, startbuf, zp.isdst, startttisstd, startttisut)
+			if err != nil {
+				return nil, err
+			}
+			if usestart {
+				c.addtt(starttime, typ)
+				usestart = false
+			} else {
+				defaulttype = typ
+			}
+		} else {
+		years:
+			for year := minYear; year <= maxYear || extend && year <= maxYear+2; year++ {
+				if useuntil && year > zp.untilrule.hiyear {
+					break
+				}
+				// Mark which rules to do in the current year.
+				for j := range zp.rules {
+					rp := &zp.rules[j]
+					rp.todo = year >= rp.loyear && year <= rp.hiyear
+					if rp.todo {
+						rp.temp = rpytime(rp, year)
+						rp.todo = rp.temp < y2038Boundary || year <= maxYear0
+					}
+				}
+				for {
+					if useuntil {
+						// Turn untiltime into UT assuming the current stdoff and save values.
+						untiltime = zp.untiltime
+						if !zp.untilrule.todisut {
+							untiltime = tadd(untiltime, -stdoff)
+						}
+						if !zp.untilrule.todisstd {
+							untiltime = tadd(untiltime, -save)
+						}
+					}
+					// Find the rule that takes effect earliest in the year.
+					k := -1
+					var ktime int64
+					for j := range zp.rules {
+						r := &zp.rules[j]
+						if !r.todo {
+							continue
+						}
+						offset := stdoff
+						if r.todisut {
+							offset = 0
+						}
+						if !r.todisstd {
+							offset += save
+						}
+						jtime := r.temp
+						if jtime == minTime || jtime == maxTime {
+							continue
+						}
+						jtime = tadd(jtime, -offset)
+						if k < 0 || jtime < ktime {
+							k, ktime = j, jtime
+						} else if jtime == ktime {
+							return nil, fmt.Errorf("zone %s: two rules for same instant", zp.name)
+						}
+					}
+					if k < 0 {
+						break // go on to next year
+					}
+					rp := &zp.rules[k]
+					rp.todo = false
+					if useuntil && ktime >= untiltime {
+						if startbuf == "" && zp.stdoff+rp.save == startoff {
+							startbuf = doabbr(zp, letterPtr(rp), rp.isdst, rp.save, false)
+						}
+						break
+					}
+					save = rp.save
+					if usestart && ktime == starttime {
+						usestart = false
+					}
+					if usestart {
+						if ktime < starttime {
+							startoff = zp.stdoff + save
+							startbuf = doabbr(zp, letterPtr(rp), rp.isdst, rp.save, false)
+							continue
+						}
+						if startbuf == "" && startoff == zp.stdoff+save {
+							startbuf = doabbr(zp, letterPtr(rp), rp.isdst, rp.save, false)
+						}
+					}
+					ab := doabbr(zp, letterPtr(rp), rp.isdst, rp.save, false)
+					offset := zp.stdoff + rp.save
+					// Slim output leaves the rest to the footer once two rules through "max"
+					// follow each other. Unlike zic 2022 and earlier, which loses the
+					// one-off rules of Asia/Gaza after 2073, wait for the last such rule.
+					if !c.wantBloat() && !useuntil && !doExtend && prevrp != nil &&
+						rp.hiyear == zicMax && prevrp.hiyear == zicMax && year > lastFiniteYear {
+						break years
+					}
+					typ, err := c.addtype(offset, ab, rp.isdst, rp.todisstd, rp.todisut)
+					if err != nil {
+						return nil, err
+					}
+					if defaulttype < 0 && !rp.isdst {
+						defaulttype = typ
+					}
+					if rp.hiyear == zicMax && !(lastatmax >= 0 && ktime < c.attypes[lastatmax].at) {
+						lastatmax = len(c.attypes)
+					}
+					c.addtt(ktime, typ)
+					prevrp = rp
+				}
+			}
+		}
+		if usestart {
+			isdst := startoff != zp.stdoff
+			if startbuf == "" && zp.format != "" {
+				disabled := disablePercentS
+				startbuf = doabbr(zp, &disabled, isdst, save, false)
+			}
+			if startbuf == "" {
+				return nil, fmt.Errorf("zone %s: can't determine time zone abbreviation to use just after until time", zp.name)
+			}
+			typ, err := c.addtype(startoff, startbuf, isdst, startttisstd, startttisut)
+			if err != nil {
+				return nil, err
+			}
+			if defaulttype < 0 && !isdst {
+				defaulttype = typ
+			}
+			c.addtt(starttime, typ)
+		}
+		// Now we may get to set starttime for the next zone line.
+		if useuntil {
+			startttisstd = zp.untilrule.todisstd
+			startttisut = zp.untilrule.todisut
+			starttime = zp.untiltime
+			if !startttisstd {
+				starttime = tadd(starttime, -save)
+			}
+			if !startttisut {
+				starttime = tadd(starttime, -stdoff)
+			}
+		}
+	}
+	if defaulttype < 0 {
+		defaulttype = 0
+	}
+	if lastatmax >= 0 {
+		c.attypes[lastatmax].dontmerge = true
+	}
+	if doExtend {
+		// If the explicit observations do not reach the end of the 400-year period,
+		// add a redundant one at the end of the final year to claim the lack of
+		// transitions up to that point.
+		xr := zrule{month: 0, dycode: dcDOM, dayofmonth: 1}
+		var lastat *attype
+		for i := range c.attypes {
+			if lastat == nil || c.attypes[i].at > lastat.at {
+				lastat = &c.attypes[i]
+			}
+		}
+		if lastat == nil || lastat.at < rpytime(&xr, maxYear-1) {
+			typ := defaulttype
+			if lastat != nil {
+				typ = lastat.typ
+			}
+			c.addtt(rpytime(&xr, maxYear+1), typ)
+			c.attypes[len(c.attypes)-1].dontmerge = true
+		}
+	}
+	return c.writezone(envvar, version, defaulttype)
+}
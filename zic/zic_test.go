@@ -0,0 +1,238 @@
+package zic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tzlist/rfc9636"
+	"github.com/tzlist/tzsource"
+)
+
+func parse(t *testing.T, source string) *tzsource.Data {
+	t.Helper()
+	d := &tzsource.Data{}
+	if err := d.Parse(strings.NewReader(source), "test"); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func footer(tzif []byte) string {
+	s := strings.TrimSuffix(string(tzif), "\n")
+	return s[strings.LastIndexByte(s, '\n')+1:]
+}
+
+func TestFooter(t *testing.T) {
+	d := parse(t, `
+Rule	US	2007	max	-	Mar	Sun>=8	2:00	1:00	D
+Rule	US	2007	max	-	Nov	Sun>=1	2:00	0	S
+Zone	Test/NewYork	-5:00	US	E%sT
+Rule	IE	1981	max	-	Mar	lastSun	1:00u	0	-
+Rule	IE	1996	max	-	Oct	lastSun	1:00u	-1:00	-
+Zone	Test/Dublin	1:00	IE	IST/GMT
+Rule	Q	2020	max	-	Mar	Sun>=8	25:00	1:00	-
+Rule	Q	2020	max	-	Nov	Sun<=7	-1:00	0	-
+Zone	Test/Q	-3:00	Q	-03/-02
+Zone	Test/Kolkata	5:30	-	IST
+Zone	Test/Offset	4:00	-	%z
+Rule	P	2010	max	-	Jan	1	0:00	1:00	D
+Zone	Test/PermanentDST	-5:00	P	E%sT
+`)
+	for _, test := range []struct {
+		zone, footer string
+		version      byte
+	}{
+		{"Test/NewYork", "EST5EDT,M3.2.0,M11.1.0", '2'},
+		{"Test/Dublin", "IST-1GMT0,M10.5.0,M3.5.0/1", '2'},
+		{"Test/Q", "<-03>3<-02>,M3.2.0/25,M11.1.0/-1", '3'},
+		{"Test/Kolkata", "IST-5:30", '2'},
+		{"Test/Offset", "<+04>-4", '2'},
+		{"Test/PermanentDST", "", '2'},
+	} {
+		zone, _ := d.Zone(test.zone)
+		tzif, err := CompileZone(d, zone, DefaultOptions)
+		if err != nil {
+			t.Fatalf("%s: %v", test.zone, err)
+		}
+		if got := footer(tzif); got != test.footer {
+			t.Errorf("%s footer = %q, want %q", test.zone, got, test.footer)
+		}
+		if tzif[4] != test.version {
+			t.Errorf("%s version = %c, want %c", test.zone, tzif[4], test.version)
+		}
+		if _, err := rfc9636.LoadLocationFromTZData(test.zone, tzif); err != nil {
+			t.Errorf("%s: %v", test.zone, err)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for _, test := range []struct {
+		arg    string
+		lo, hi int64
+		ok     bool
+	}{
+		{"@0/@100", 0, 99, true},
+		{"@-100", -100, NoHi, true},
+		{"/@100", NoLo, 99, true},
+		{"@100/@0", 0, 0, false},
+		{"100", 0, 0, false},
+		{"@x", 0, 0, false},
+	} {
+		opts := DefaultOptions
+		err := ParseRange(&opts, test.arg)
+		if (err == nil) != test.ok || (test.ok && (opts.Lo != test.lo || opts.Hi != test.hi)) {
+			t.Errorf("ParseRange(%q) = %d, %d, %v", test.arg, opts.Lo, opts.Hi, err)
+		}
+	}
+}
+
+// TestCompileSystem compiles the system tzdata.zi and checks that the result describes
+// the same transitions and footer as the installed files, which come from the C zic.
+func TestCompileSystem(t *testing.T) {
+	const root = "/usr/share/zoneinfo/"
+	d, err := tzsource.ReadFile(root + "tzdata.zi")
+	if err != nil {
+		t.Skip("no tzdata.zi on this system")
+	}
+	for _, fat := range []bool{false, true} {
+		files, err := Compile(d, Options{Fat: fat, Lo: NoLo, Hi: NoHi})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, tzif := range files {
+			installed, err := os.ReadFile(root + name)
+			if err != nil {
+				continue
+			}
+			want, err := rfc9636.LoadLocationFromTZData(name, installed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := rfc9636.LoadLocationFromTZData(name, tzif)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got.Extend() != want.Extend() {
+				t.Errorf("%s footer = %q, want %q", name, got.Extend(), want.Extend())
+			}
+			from, to := int64(-5e9), int64(5e9)
+			gt, wt := got.Transitions(from, to), want.Transitions(from, to)
+			if len(gt) != len(wt) {
+				t.Errorf("%s (fat %v) has %d transitions, want %d", name, fat, len(gt), len(wt))
+				continue
+			}
+			for i := range gt {
+				if gt[i] != wt[i] {
+					t.Errorf("%s transition %d = %+v, want %+v", name, i, gt[i], wt[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+// TestCompileSystemBytes checks the byte for byte claim against installed zic -b fat output.
+func TestCompileSystemBytes(t *testing.T) {
+	const root = "/usr/share/zoneinfo/"
+	d, err := tzsource.ReadFile(root + "tzdata.zi")
+	if err != nil {
+		t.Skip("no tzdata.zi on this system")
+	}
+	// Slim files have no transitions in the version 1 data block.
+	sample, err := os.ReadFile(root + "America/New_York")
+	if err != nil || len(sample) < 44 || binary.BigEndian.Uint32(sample[32:]) == 0 {
+		t.Skip("the installed zones are not fat")
+	}
+	files, err := Compile(d, Options{Fat: true, Lo: NoLo, Hi: NoHi})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compared := 0
+	for name, tzif := range files {
+		installed, err := os.ReadFile(root + name)
+		if err != nil {
+			continue
+		}
+		compared++
+		if !bytes.Equal(tzif, installed) {
+			t.Errorf("%s: %d bytes differ from the %d installed ones", name, len(tzif), len(installed))
+		}
+	}
+	if compared == 0 {
+		t.Error("no installed zone to compare with")
+	}
+}
+
+func TestDecompile(t *testing.T) {
+	d := parse(t, `
+Rule	EU	1981	max	-	Mar	lastSun	1:00u	1:00	S
+Rule	EU	1981	1995	-	Sep	lastSun	1:00u	0	-
+Rule	EU	1996	max	-	Oct	lastSun	1:00u	0	-
+Zone	Test/Berlin	0:53:28	-	LMT	1893 Apr
+			1:00	-	CET	1980
+			1:00	EU	CE%sT
+`)
+	zone, _ := d.Zone("Test/Berlin")
+	tzif, err := CompileZone(d, zone, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, err := rfc9636.LoadLocationFromTZData("Test/Berlin", tzif)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Decompile(loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, rule := range data.Rules["Berlin"] {
+		lines = append(lines, rule.String())
+	}
+	lines = append(lines, data.Zones[0].String())
+	want := []string{
+		"Rule\tBerlin\t1981\t1995\t-\tMar\tlastSun\t2:00\t1:00\tS",
+		"Rule\tBerlin\t1981\t1995\t-\tSep\tlastSun\t3:00\t0:00\t-",
+		"Rule\tBerlin\t1996\tmax\t-\tMar\tlastSun\t2:00\t1:00\tS",
+		"Rule\tBerlin\t1996\tmax\t-\tOct\tlastSun\t3:00\t0:00\t-",
+		"Zone\tTest/Berlin\t0:53:28\t-\tLMT\t1893 Apr\n\t\t\t1:00\tBerlin\tCE%sT",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Decompile =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+	if err := Verify(loc, data); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestDecompileSystem decompiles the installed zones and checks that the source compiles
+// back to the same local time types.
+func TestDecompileSystem(t *testing.T) {
+	const root = "/usr/share/zoneinfo/"
+	d, err := tzsource.ReadFile(root + "tzdata.zi")
+	if err != nil {
+		t.Skip("no tzdata.zi on this system")
+	}
+	for _, zone := range d.Zones {
+		installed, err := os.ReadFile(root + zone.Name)
+		if err != nil {
+			continue
+		}
+		loc, err := rfc9636.LoadLocationFromTZData(zone.Name, installed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := Decompile(loc)
+		if err != nil {
+			t.Errorf("%s: %v", zone.Name, err)
+			continue
+		}
+		if err := Verify(loc, data); err != nil {
+			t.Error(err)
+		}
+	}
+}
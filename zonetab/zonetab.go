@@ -0,0 +1,133 @@
+// Package zonetab reads the zone1970.tab, zone.tab and iso3166.tab tables that
+// accompany the tz database and map time zones to countries and locations.
+// See the comments at the top of each file in the tzdata distribution.
+package zonetab
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// A Zone is one row of zone1970.tab or zone.tab.
+// zone.tab rows have a single country code.
+type Zone struct {
+	Countries   []string // ISO 3166 alpha-2 codes, the most populous first
+	Coordinates string   // ISO 6709 latitude and longitude of the principal location
+	Latitude    float64  // degrees, north positive
+	Longitude   float64  // degrees, east positive
+	TZ          string
+	Comment     string // distinguishes zones of the same country, often empty
+}
+
+// ReadZoneTab reads zone1970.tab or zone.tab from path.
+func ReadZoneTab(path string) ([]Zone, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseZoneTab(f)
+}
+
+// ParseZoneTab parses the tab separated rows of zone1970.tab or zone.tab.
+func ParseZoneTab(r io.Reader) ([]Zone, error) {
+	var zones []Zone
+	err := scanTab(r, 3, func(fields []string) error {
+		lat, lon, err := ParseCoordinates(fields[1])
+		if err != nil {
+			return err
+		}
+		z := Zone{Countries: strings.Split(fields[0], ","), Coordinates: fields[1], Latitude: lat, Longitude: lon, TZ: fields[2]}
+		if len(fields) > 3 {
+			z.Comment = fields[3]
+		}
+		zones = append(zones, z)
+		return nil
+	})
+	return zones, err
+}
+
+// ReadISO3166 reads iso3166.tab from path and returns the country names by code.
+func ReadISO3166(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseISO3166(f)
+}
+
+// ParseISO3166 parses the tab separated code and name rows of iso3166.tab.
+func ParseISO3166(r io.Reader) (map[string]string, error) {
+	countries := make(map[string]string)
+	err := scanTab(r, 2, func(fields []string) error {
+		countries[fields[0]] = fields[1]
+		return nil
+	})
+	return countries, err
+}
+
+// scanTab calls row with the tab separated fields of each line that is not blank or a comment.
+func scanTab(r io.Reader, minFields int, row func(fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < minFields {
+			return fmt.Errorf("line %d: expected %d tab separated fields, got %d", lineNo, minFields, len(fields))
+		}
+		if err := row(fields); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// ParseCoordinates converts ISO 6709 coordinates in the form ±DDMM±DDDMM or
+// ±DDMMSS±DDDMMSS to decimal degrees.
+func ParseCoordinates(s string) (lat, lon float64, err error) {
+	split := strings.IndexAny(s[min(1, len(s)):], "+-") + 1
+	if split <= 0 {
+		return 0, 0, fmt.Errorf("invalid coordinates %q", s)
+	}
+	if lat, err = parseDegrees(s[:split], 2); err == nil {
+		lon, err = parseDegrees(s[split:], 3)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid coordinates %q: %w", s, err)
+	}
+	return lat, lon, nil
+}
+
+// parseDegrees converts ±DDMM or ±DDMMSS, with width digits of degrees, to decimal degrees.
+func parseDegrees(s string, width int) (float64, error) {
+	if (len(s) != 1+width+2 && len(s) != 1+width+4) || (s[0] != '+' && s[0] != '-') {
+		return 0, fmt.Errorf("malformed %q", s)
+	}
+	var parts [3]int
+	digits := s[1:]
+	for i, n := range []int{width, 2, 2} {
+		if digits == "" {
+			break
+		}
+		v, err := strconv.Atoi(digits[:n])
+		if err != nil {
+			return 0, err
+		}
+		parts[i], digits = v, digits[n:]
+	}
+	degrees := float64(parts[0]) + float64(parts[1])/60 + float64(parts[2])/3600
+	if s[0] == '-' {
+		degrees = -degrees
+	}
+	return degrees, nil
+}
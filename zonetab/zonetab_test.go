@@ -0,0 +1,57 @@
+package zonetab
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseCoordinates(t *testing.T) {
+	var tests = []struct {
+		coordinates string
+		lat, lon    float64
+	}{
+		{"+4230+00131", 42.5, 1 + 31.0/60},
+		{"-720041+0023206", -(72 + 41.0/3600), 2 + 32.0/60 + 6.0/3600},
+		{"+744144-0944945", 74 + 41.0/60 + 44.0/3600, -(94 + 49.0/60 + 45.0/3600)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.coordinates, func(t *testing.T) {
+			lat, lon, err := ParseCoordinates(tt.coordinates)
+			if err != nil {
+				t.Fatalf("got %v, want nil", err)
+			}
+			if math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lon-tt.lon) > 1e-9 {
+				t.Errorf("got %v %v, want %v %v", lat, lon, tt.lat, tt.lon)
+			}
+		})
+	}
+	for _, bad := range []string{"", "+", "4230+00131", "+4230+0131", "+42a0+00131"} {
+		if _, _, err := ParseCoordinates(bad); err == nil {
+			t.Errorf("%q: got nil, want an error", bad)
+		}
+	}
+}
+
+func TestParseZoneTab(t *testing.T) {
+	const tab = "# comment\n" +
+		"AE,OM,RE,SC,TF\t+2518+05518\tAsia/Dubai\tCrozet\n" +
+		"\n" +
+		"AD\t+4230+00131\tEurope/Andorra\n"
+	zones, err := ParseZoneTab(strings.NewReader(tab))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(zones) != 2 {
+		t.Fatalf("got %d zones, want 2", len(zones))
+	}
+	if z := zones[0]; z.TZ != "Asia/Dubai" || len(z.Countries) != 5 || z.Countries[4] != "TF" || z.Comment != "Crozet" {
+		t.Errorf("got %+v", z)
+	}
+	if z := zones[1]; z.TZ != "Europe/Andorra" || len(z.Countries) != 1 || z.Comment != "" {
+		t.Errorf("got %+v", z)
+	}
+	if _, err := ParseZoneTab(strings.NewReader("AD\t+4230+00131\n")); err == nil {
+		t.Errorf("short row: got nil, want an error")
+	}
+}